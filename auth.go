@@ -0,0 +1,184 @@
+package hippo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds authentication to API requests
+type Authenticator interface {
+	// Authenticate decorates the request with whatever it needs to authenticate
+	Authenticate(req *http.Request) error
+}
+
+// EmailTokenAuth authenticates with a user's email and API token
+type EmailTokenAuth struct {
+	Email string
+	Token string
+}
+
+// Authenticate sets the email and token headers on the request
+func (a EmailTokenAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("X-CH-Auth-Email", a.Email)
+	req.Header.Set("X-CH-Auth-API-Token", a.Token)
+	return nil
+}
+
+func (a EmailTokenAuth) secrets() []string {
+	return []string{a.Token}
+}
+
+// BearerTokenAuth authenticates with a bearer token
+type BearerTokenAuth struct {
+	Token string
+}
+
+// Authenticate sets the Authorization header on the request
+func (a BearerTokenAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+func (a BearerTokenAuth) secrets() []string {
+	return []string{a.Token}
+}
+
+// Credentials are an email and API token, or just a token for bearer auth
+type Credentials struct {
+	Email string
+	Token string
+}
+
+// CredentialsProvider provides the current credentials. It's called for every request,
+// so it should be cheap, and safe to call from multiple goroutines.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// CredentialsProviderFunc adapts a function to a CredentialsProvider
+type CredentialsProviderFunc func() (Credentials, error)
+
+// Credentials calls the function
+func (f CredentialsProviderFunc) Credentials() (Credentials, error) {
+	return f()
+}
+
+// ProviderAuth authenticates with credentials looked up from a provider for every request,
+// so credentials can be rotated without rebuilding the Client
+type ProviderAuth struct {
+	provider CredentialsProvider
+	bearer   bool
+
+	lock      sync.Mutex
+	lastToken string // so it can be redacted from logs
+}
+
+// NewProviderAuth builds an Authenticator that sends the provider's email and token
+func NewProviderAuth(provider CredentialsProvider) *ProviderAuth {
+	return &ProviderAuth{provider: provider}
+}
+
+// NewBearerProviderAuth builds an Authenticator that sends the provider's token as a bearer token
+func NewBearerProviderAuth(provider CredentialsProvider) *ProviderAuth {
+	return &ProviderAuth{provider: provider, bearer: true}
+}
+
+// Authenticate looks up the current credentials, and sets them on the request
+func (a *ProviderAuth) Authenticate(req *http.Request) error {
+	creds, err := a.provider.Credentials()
+	if err != nil {
+		return fmt.Errorf("Error looking up credentials: %s", err)
+	}
+	if creds.Token == "" {
+		return fmt.Errorf("Credentials provider returned an empty token")
+	}
+	a.lock.Lock()
+	a.lastToken = creds.Token
+	a.lock.Unlock()
+
+	if a.bearer {
+		return BearerTokenAuth{Token: creds.Token}.Authenticate(req)
+	}
+	return EmailTokenAuth{Email: creds.Email, Token: creds.Token}.Authenticate(req)
+}
+
+func (a *ProviderAuth) secrets() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return []string{a.lastToken}
+}
+
+// EnvCredentialsProvider reads credentials from environment variables every time they're needed
+type EnvCredentialsProvider struct {
+	EmailVar string // optional - not needed for bearer auth
+	TokenVar string
+}
+
+// Credentials reads the environment variables
+func (p EnvCredentialsProvider) Credentials() (Credentials, error) {
+	token := os.Getenv(p.TokenVar)
+	if token == "" {
+		return Credentials{}, fmt.Errorf("Environment variable %s is not set", p.TokenVar)
+	}
+
+	creds := Credentials{Token: token}
+	if p.EmailVar != "" {
+		creds.Email = os.Getenv(p.EmailVar)
+	}
+	return creds, nil
+}
+
+// FileCredentialsProvider reads the token from a file, re-reading it whenever the file changes.
+// Surrounding whitespace in the file is ignored.
+type FileCredentialsProvider struct {
+	email     string
+	tokenPath string
+
+	lock    sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileCredentialsProvider builds a new FileCredentialsProvider
+// - email is optional - it's not needed for bearer auth
+func NewFileCredentialsProvider(email string, tokenPath string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{
+		email:     email,
+		tokenPath: tokenPath,
+	}
+}
+
+// Credentials returns the token from the file, re-reading the file if it's changed since last time
+func (p *FileCredentialsProvider) Credentials() (Credentials, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	info, err := os.Stat(p.tokenPath)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("Error checking token file: %s", err)
+	}
+
+	if p.token == "" || !info.ModTime().Equal(p.modTime) || info.Size() != p.size {
+		tokenBytes, err := ioutil.ReadFile(p.tokenPath)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("Error reading token file: %s", err)
+		}
+		token := strings.TrimSpace(string(tokenBytes))
+		if token == "" {
+			return Credentials{}, fmt.Errorf("Token file '%s' is empty", p.tokenPath)
+		}
+
+		p.token = token
+		p.modTime = info.ModTime()
+		p.size = info.Size()
+	}
+
+	return Credentials{Email: p.email, Token: p.token}, nil
+}
@@ -0,0 +1,126 @@
+package hippo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailTokenAuth(t *testing.T) {
+	a := require.New(t)
+
+	req, err := http.NewRequest("GET", "http://localhost", nil)
+	a.NoError(err)
+	a.NoError(EmailTokenAuth{Email: "email", Token: "token"}.Authenticate(req))
+	a.Equal("email", req.Header.Get("X-CH-Auth-Email"))
+	a.Equal("token", req.Header.Get("X-CH-Auth-API-Token"))
+	a.Empty(req.Header.Get("Authorization"))
+}
+
+func TestBearerTokenAuth(t *testing.T) {
+	a := require.New(t)
+
+	req, err := http.NewRequest("GET", "http://localhost", nil)
+	a.NoError(err)
+	a.NoError(BearerTokenAuth{Token: "token"}.Authenticate(req))
+	a.Equal("Bearer token", req.Header.Get("Authorization"))
+	a.Empty(req.Header.Get("X-CH-Auth-API-Token"))
+}
+
+func TestProviderAuth_Env(t *testing.T) {
+	a := require.New(t)
+
+	a.NoError(os.Setenv("GOHIPPO_TEST_EMAIL", "email"))
+	a.NoError(os.Setenv("GOHIPPO_TEST_TOKEN", "token1"))
+	defer os.Unsetenv("GOHIPPO_TEST_EMAIL")
+	defer os.Unsetenv("GOHIPPO_TEST_TOKEN")
+
+	sut := NewProviderAuth(EnvCredentialsProvider{EmailVar: "GOHIPPO_TEST_EMAIL", TokenVar: "GOHIPPO_TEST_TOKEN"})
+
+	req, err := http.NewRequest("GET", "http://localhost", nil)
+	a.NoError(err)
+	a.NoError(sut.Authenticate(req))
+	a.Equal("email", req.Header.Get("X-CH-Auth-Email"))
+	a.Equal("token1", req.Header.Get("X-CH-Auth-API-Token"))
+
+	// rotate the token
+	a.NoError(os.Setenv("GOHIPPO_TEST_TOKEN", "token2"))
+	a.NoError(sut.Authenticate(req))
+	a.Equal("token2", req.Header.Get("X-CH-Auth-API-Token"))
+
+	// missing token fails the request
+	a.NoError(os.Unsetenv("GOHIPPO_TEST_TOKEN"))
+	a.Error(sut.Authenticate(req))
+}
+
+func TestProviderAuth_File(t *testing.T) {
+	a := require.New(t)
+
+	dir, err := ioutil.TempDir("", "gohippo")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "token")
+	a.NoError(ioutil.WriteFile(tokenPath, []byte("token1\n"), 0600))
+
+	sut := NewBearerProviderAuth(NewFileCredentialsProvider("", tokenPath))
+
+	req, err := http.NewRequest("GET", "http://localhost", nil)
+	a.NoError(err)
+	a.NoError(sut.Authenticate(req))
+	a.Equal("Bearer token1", req.Header.Get("Authorization"))
+
+	// rotate the token - make sure the modification time changes, even on coarse filesystems
+	a.NoError(ioutil.WriteFile(tokenPath, []byte("token2\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	a.NoError(os.Chtimes(tokenPath, later, later))
+	a.NoError(sut.Authenticate(req))
+	a.Equal("Bearer token2", req.Header.Get("Authorization"))
+
+	// empty and missing files fail the request
+	a.NoError(ioutil.WriteFile(tokenPath, []byte(" \n"), 0600))
+	a.Error(sut.Authenticate(req))
+	a.NoError(os.Remove(tokenPath))
+	a.Error(sut.Authenticate(req))
+}
+
+// Test that the client uses its authenticator, and that it can be swapped while in use
+func TestClient_Authenticator(t *testing.T) {
+	a := require.New(t)
+
+	receivedTokens := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedTokens = append(receivedTokens, r.Header.Get("X-CH-Auth-API-Token")+r.Header.Get("Authorization"))
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"customDimensions":[]}`))
+	}))
+	defer ts.Close()
+
+	sut, err := NewClient(WithCredentials("email", "token"))
+	a.NoError(err)
+	_, err = sut.EnsureDimensions(context.Background(), ts.URL, map[string]string{})
+	a.NoError(err)
+
+	rotation := 0
+	sut.SetAuthenticator(NewProviderAuth(CredentialsProviderFunc(func() (Credentials, error) {
+		rotation++
+		return Credentials{Email: "email", Token: fmt.Sprintf("rotated%d", rotation)}, nil
+	})))
+	_, err = sut.EnsureDimensions(context.Background(), ts.URL, map[string]string{})
+	a.NoError(err)
+	_, err = sut.EnsureDimensions(context.Background(), ts.URL, map[string]string{})
+	a.NoError(err)
+
+	sut.SetAuthenticator(BearerTokenAuth{Token: "bearer"})
+	_, err = sut.EnsureDimensions(context.Background(), ts.URL, map[string]string{})
+	a.NoError(err)
+
+	a.Equal([]string{"token", "rotated1", "rotated2", "Bearer bearer"}, receivedTokens)
+}
@@ -13,12 +13,16 @@ type BatchBuilder struct {
 	desiredSize       int
 	buf               *bytes.Buffer
 	serializedUpserts [][]byte
+	serializedDeletes [][]byte
 	batchGUID         string
 	replaceAll        bool
 	ttlMinutes        uint32
 	builtBatchesCount int
 	hasClosedBatch    bool
 	sender            TagBatchPartSender // optional - to help track batch origin
+	compressionRatio  float64            // expected compressed/JSON ratio - zero sizes parts by their JSON
+	lastUpserts       [][]byte           // upserts in the last part built
+	lastDeletes       [][]byte           // deletes in the last part built
 }
 
 // NewBatchBuilder builds a new BatchBuilder
@@ -26,6 +30,7 @@ func NewBatchBuilder(desiredSize int, replaceAll bool, ttlMinutes uint32) *Batch
 	return &BatchBuilder{
 		desiredSize:       desiredSize,
 		serializedUpserts: make([][]byte, 0),
+		serializedDeletes: make([][]byte, 0),
 		replaceAll:        replaceAll,
 		ttlMinutes:        ttlMinutes,
 		buf:               bytes.NewBuffer(make([]byte, 0, desiredSize)),
@@ -36,11 +41,15 @@ func NewBatchBuilder(desiredSize int, replaceAll bool, ttlMinutes uint32) *Batch
 func (b *BatchBuilder) Reset(desiredSize int, replaceAll bool, ttlMinutes uint32) {
 	b.desiredSize = desiredSize
 	b.serializedUpserts = b.serializedUpserts[:0]
+	b.serializedDeletes = b.serializedDeletes[:0]
 	b.batchGUID = ""
 	b.replaceAll = replaceAll
 	b.ttlMinutes = ttlMinutes
 	b.builtBatchesCount = 0
 	b.hasClosedBatch = false
+	b.compressionRatio = 0
+	b.lastUpserts = nil
+	b.lastDeletes = nil
 }
 
 // SetSenderInfo sets optional metadata about the service sending batches
@@ -48,8 +57,8 @@ func (b *BatchBuilder) SetSenderInfo(sender TagBatchPartSender) {
 	b.sender = sender
 }
 
-func (b *BatchBuilder) isSenderInfoSet() bool {
-	return b.sender.ServiceName != "" || b.sender.ServiceInstance != "" || b.sender.HostName != ""
+func isSenderInfoSet(sender TagBatchPartSender) bool {
+	return sender.ServiceName != "" || sender.ServiceInstance != "" || sender.HostName != ""
 }
 
 // AddUpsert attempts to add the input upsert into the batch.
@@ -67,28 +76,53 @@ func (b *BatchBuilder) AddUpsert(upsert *TagUpsert) error {
 	return nil
 }
 
+// AddDelete attempts to add the input delete into the batch.
+// Make sure to add all deletes to the batch before calling BuildBatch()
+func (b *BatchBuilder) AddDelete(del *TagDelete) error {
+	if b.builtBatchesCount > 0 {
+		return fmt.Errorf("Cannot add delete after a batch has been built")
+	}
+
+	ser, err := json.Marshal(del)
+	if err != nil {
+		return fmt.Errorf("Error serializing TagDelete: %s", err)
+	}
+	b.serializedDeletes = append(b.serializedDeletes, ser)
+	return nil
+}
+
 // SetBatchGUID sets the GUID that was returned after submitting the first part to the server.
 func (b *BatchBuilder) SetBatchGUID(guid string) {
 	b.batchGUID = guid
 }
 
 // BuildBatchRequest builds and returns a serialized batch part request.
-// Once this method is called, don't make any further calls to AddUpsert.
-// - returns serialized batch and upsert count
-// - if there are upserts, it will make sure to include at least one in the batch,
-//   even if the batch goes over the limit. This is to give the batch a shot, in case
-//   the configured limit is still lower than what the server will allow.
+// Once this method is called, don't make any further calls to AddUpsert or AddDelete.
+//   - returns serialized batch and upsert count
+//   - if there are upserts, it will make sure to include at least one in the batch,
+//     even if the batch goes over the limit. This is to give the batch a shot, in case
+//     the configured limit is still lower than what the server will allow.
 func (b *BatchBuilder) BuildBatchRequest() ([]byte, int, error) {
-	if len(b.serializedUpserts) == 0 && (b.hasClosedBatch || !b.replaceAll) {
+	batchBytes, upsertCount, _, err := b.BuildBatchRequestCounts()
+	return batchBytes, upsertCount, err
+}
+
+// BuildBatchRequestCounts works like BuildBatchRequest, but also returns the count of deletes in the part.
+// - returns serialized batch, upsert count, and delete count
+// - deletes are packed into whatever space the upserts leave over
+func (b *BatchBuilder) BuildBatchRequestCounts() ([]byte, int, int, error) {
+	if len(b.serializedUpserts) == 0 && len(b.serializedDeletes) == 0 && (b.hasClosedBatch || !b.replaceAll) {
 		// nothing to do
-		return nil, 0, nil
+		return nil, 0, 0, nil
 	}
 
 	if b.batchGUID == "" && b.builtBatchesCount > 0 {
-		return nil, 0, fmt.Errorf("Only first batch may be sent without batch GUID")
+		return nil, 0, 0, fmt.Errorf("Only first batch may be sent without batch GUID")
 	}
 
 	b.buf.Reset()
+	b.lastUpserts = make([][]byte, 0)
+	b.lastDeletes = make([][]byte, 0)
 
 	if b.builtBatchesCount == 0 {
 		// sort upserts by serialized size - only needs to happen once
@@ -97,48 +131,8 @@ func (b *BatchBuilder) BuildBatchRequest() ([]byte, int, error) {
 		})
 	}
 
-	// guid
-	if _, err := b.buf.WriteString(`{"guid":"`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
-	}
-	if _, err := b.buf.WriteString(b.batchGUID); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
-	}
-
-	// replace_all
-	if _, err := b.buf.WriteString(`","replace_all":`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
-	}
-	if _, err := b.buf.WriteString(boolString(b.replaceAll)); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
-	}
-
-	// ttl_minutes
-	if _, err := b.buf.WriteString(`,"ttl_minutes":`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
-	}
-	if _, err := b.buf.WriteString(fmt.Sprintf("%d", b.ttlMinutes)); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
-	}
-
-	// service info, if set
-	if b.isSenderInfoSet() {
-		senderBytes, err := json.Marshal(b.sender)
-		if err != nil {
-			return nil, 0, fmt.Errorf("Error marshalling sender info to JSON: %s", err)
-		}
-
-		if _, err := b.buf.WriteString(`,"sender":`); err != nil {
-			return nil, 0, fmt.Errorf("Error writing sender info to buffer: %s", err)
-		}
-		if _, err := b.buf.Write(senderBytes); err != nil {
-			return nil, 0, fmt.Errorf("Error writing sender info to buffer: %s", err)
-		}
-	}
-
-	// upserts start
-	if _, err := b.buf.WriteString(`,"upserts":[`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+	if err := writeBatchPartHeader(b.buf, b.batchGUID, b.replaceAll, b.ttlMinutes, b.sender); err != nil {
+		return nil, 0, 0, err
 	}
 
 	// build a batch as big as we can
@@ -146,7 +140,7 @@ func (b *BatchBuilder) BuildBatchRequest() ([]byte, int, error) {
 	end := len(b.serializedUpserts) - 1
 
 	// leave some space for batch scaffolding and `"complete":false`
-	availableSpace := b.desiredSize - b.buf.Len() - 40
+	availableSpace := compressedTargetSize(b.desiredSize, b.compressionRatio) - b.buf.Len() - 40
 
 	upsertCount := 0
 	for start <= end && availableSpace > 0 {
@@ -154,15 +148,16 @@ func (b *BatchBuilder) BuildBatchRequest() ([]byte, int, error) {
 		if len(b.serializedUpserts[end]) <= availableSpace {
 			if upsertCount > 0 {
 				if _, err := b.buf.WriteString(","); err != nil {
-					return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+					return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 				}
 				availableSpace--
 			}
 			if _, err := b.buf.Write(b.serializedUpserts[end]); err != nil {
-				return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+				return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 			}
 			availableSpace -= len(b.serializedUpserts[end])
 
+			b.lastUpserts = append(b.lastUpserts, b.serializedUpserts[end])
 			b.serializedUpserts[end] = nil
 
 			upsertCount++
@@ -175,15 +170,16 @@ func (b *BatchBuilder) BuildBatchRequest() ([]byte, int, error) {
 		if upsertCount == 0 || len(b.serializedUpserts[start]) <= availableSpace {
 			if upsertCount > 0 {
 				if _, err := b.buf.WriteString(","); err != nil {
-					return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+					return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 				}
 				availableSpace--
 			}
 			if _, err := b.buf.Write(b.serializedUpserts[start]); err != nil {
-				return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+				return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 			}
 			availableSpace -= len(b.serializedUpserts[start])
 
+			b.lastUpserts = append(b.lastUpserts, b.serializedUpserts[start])
 			b.serializedUpserts[start] = nil
 
 			upsertCount++
@@ -195,26 +191,158 @@ func (b *BatchBuilder) BuildBatchRequest() ([]byte, int, error) {
 	}
 
 	if _, err := b.buf.WriteString(`]`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+
+	// deletes - only once the upserts are all placed, filling in whatever space is left
+	// - if the batch is still empty, we include one delete anyway
+	deleteCount := 0
+	if start > end {
+		for deleteCount < len(b.serializedDeletes) {
+			ser := b.serializedDeletes[deleteCount]
+			needed := len(ser) + 1 // leading comma
+			if deleteCount == 0 {
+				needed = len(ser) + len(`,"deletes":[]`)
+			}
+			if upsertCount+deleteCount > 0 && needed > availableSpace {
+				break
+			}
+
+			if deleteCount == 0 {
+				if _, err := b.buf.WriteString(`,"deletes":[`); err != nil {
+					return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+				}
+				availableSpace -= len(`,"deletes":[]`)
+			} else {
+				if _, err := b.buf.WriteString(","); err != nil {
+					return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+				}
+				availableSpace--
+			}
+			if _, err := b.buf.Write(ser); err != nil {
+				return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+			}
+			availableSpace -= len(ser)
+
+			b.lastDeletes = append(b.lastDeletes, ser)
+			b.serializedDeletes[deleteCount] = nil
+			deleteCount++
+		}
+		if deleteCount > 0 {
+			if _, err := b.buf.WriteString(`]`); err != nil {
+				return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+			}
+		}
+		b.serializedDeletes = b.serializedDeletes[deleteCount:]
 	}
+	isComplete := start > end && len(b.serializedDeletes) == 0
 
 	// is_complete
 	if _, err := b.buf.WriteString(`,"complete":`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 	}
-	if _, err := b.buf.WriteString(boolString(start > end)); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+	if _, err := b.buf.WriteString(boolString(isComplete)); err != nil {
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 	}
-	b.hasClosedBatch = start > end
+	b.hasClosedBatch = isComplete
 
 	if _, err := b.buf.WriteString(`}`); err != nil {
-		return nil, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
 	}
 
 	b.serializedUpserts = b.serializedUpserts[start : end+1]
 
 	b.builtBatchesCount++
-	return b.buf.Bytes(), upsertCount, nil
+	return b.buf.Bytes(), upsertCount, deleteCount, nil
+}
+
+// IsComplete returns whether the last part built completed the batch
+func (b *BatchBuilder) IsComplete() bool {
+	return b.hasClosedBatch
+}
+
+// lastPartItems returns the serialized upserts and deletes in the last part built
+func (b *BatchBuilder) lastPartItems() ([][]byte, [][]byte) {
+	return b.lastUpserts, b.lastDeletes
+}
+
+// pendingItems returns the serialized upserts and deletes that haven't been built into a part yet
+func (b *BatchBuilder) pendingItems() ([][]byte, [][]byte) {
+	return b.serializedUpserts, b.serializedDeletes
+}
+
+// partHeader returns the fields that are the same in every part of the batch
+func (b *BatchBuilder) partHeader() partHeader {
+	return partHeader{replaceAll: b.replaceAll, ttlMinutes: b.ttlMinutes, sender: b.sender}
+}
+
+// estimateCompression estimates how well the batch will compress, so parts are sized by compressed size
+// - call it after adding all the upserts and deletes
+func (b *BatchBuilder) estimateCompression(encoder BodyEncoder) {
+	b.compressionRatio = estimateCompressionRatio(encoder, b.serializedUpserts, b.serializedDeletes)
+}
+
+// observeCompression updates the expected compression ratio, from how well the last part actually compressed
+func (b *BatchBuilder) observeCompression(rawSize int, compressedSize int) {
+	if rawSize > 0 {
+		b.compressionRatio = float64(compressedSize) / float64(rawSize)
+	}
+}
+
+// lowerDesiredSize lowers the desired size of the parts still to be built - it's never raised
+func (b *BatchBuilder) lowerDesiredSize(size int) {
+	if size < b.desiredSize {
+		b.desiredSize = size
+	}
+}
+
+// writeBatchPartHeader writes everything in a serialized batch part up to the opening of the upserts array
+func writeBatchPartHeader(buf *bytes.Buffer, batchGUID string, replaceAll bool, ttlMinutes uint32, sender TagBatchPartSender) error {
+	// guid
+	if _, err := buf.WriteString(`{"guid":"`); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if _, err := buf.WriteString(batchGUID); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+
+	// replace_all
+	if _, err := buf.WriteString(`","replace_all":`); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if _, err := buf.WriteString(boolString(replaceAll)); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+
+	// ttl_minutes
+	if _, err := buf.WriteString(`,"ttl_minutes":`); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if _, err := buf.WriteString(fmt.Sprintf("%d", ttlMinutes)); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+
+	// service info, if set
+	if isSenderInfoSet(sender) {
+		senderBytes, err := json.Marshal(sender)
+		if err != nil {
+			return fmt.Errorf("Error marshalling sender info to JSON: %s", err)
+		}
+
+		if _, err := buf.WriteString(`,"sender":`); err != nil {
+			return fmt.Errorf("Error writing sender info to buffer: %s", err)
+		}
+		if _, err := buf.Write(senderBytes); err != nil {
+			return fmt.Errorf("Error writing sender info to buffer: %s", err)
+		}
+	}
+
+	// upserts start
+	if _, err := buf.WriteString(`,"upserts":[`); err != nil {
+		return fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+
+	return nil
 }
 
 func boolString(val bool) string {
@@ -10,8 +10,8 @@ import (
 )
 
 // test batch building when every populator is too big for a single batch
-// - in this case, we send batches anyway, from the smallest to the biggest,
-//   just in case server can accept them, we wanna send out whatever we can
+//   - in this case, we send batches anyway, from the smallest to the biggest,
+//     just in case server can accept them, we wanna send out whatever we can
 func TestBatchBuilder_FailureOverLimit(t *testing.T) {
 	a := require.New(t)
 
@@ -521,3 +521,72 @@ func TestBatchBuilder_SuccessWithBigAndSmallUpserts(t *testing.T) {
 	sut.Reset(maxSize, true, 13)
 	runTest()
 }
+
+// test building a batch with upserts and deletes, where the deletes spill over into a second part
+func TestBatchBuilder_SuccessUpsertsAndDeletes(t *testing.T) {
+	a := require.New(t)
+
+	sut := NewBatchBuilder(300, false, 0)
+	a.NoError(sut.AddUpsert(&TagUpsert{
+		Value: "value_1",
+		Criteria: []TagCriteria{
+			{
+				Direction:   "src",
+				IPAddresses: []string{"1.2.3.1"},
+			},
+		},
+	}))
+	for i := 1; i <= 8; i++ {
+		a.NoError(sut.AddDelete(&TagDelete{Value: fmt.Sprintf("old_value_%d", i)}))
+	}
+
+	// part 1: the upsert, with as many deletes as will fit
+	batchBytes, upsertCount, deleteCount, err := sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.True(len(batchBytes) <= 300)
+	a.Equal(1, upsertCount)
+	a.Equal(4, deleteCount)
+	batch := TagBatchPart{}
+	a.NoError(json.Unmarshal(batchBytes, &batch))
+	a.False(batch.IsComplete)
+	a.Equal(1, len(batch.Upserts))
+	a.Equal(4, len(batch.Deletes))
+	a.Equal("old_value_1", batch.Deletes[0].Value)
+
+	// can't add anything once the first part is built
+	a.Error(sut.AddDelete(&TagDelete{Value: "too_late"}))
+
+	// part 2: the remaining deletes
+	sut.SetBatchGUID("805e4dcb-3ecd-24f3-3a35-3e926e4bded5")
+	batchBytes, upsertCount, deleteCount, err = sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Equal(0, upsertCount)
+	a.Equal(4, deleteCount)
+	batch = TagBatchPart{}
+	a.NoError(json.Unmarshal(batchBytes, &batch))
+	a.True(batch.IsComplete)
+	a.Equal(0, len(batch.Upserts))
+	a.Equal(4, len(batch.Deletes))
+	a.Equal("old_value_8", batch.Deletes[3].Value)
+
+	// done
+	batchBytes, upsertCount, deleteCount, err = sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Nil(batchBytes)
+	a.Zero(upsertCount)
+	a.Zero(deleteCount)
+}
+
+// test building a batch that only has deletes
+func TestBatchBuilder_SuccessDeletesOnly(t *testing.T) {
+	a := require.New(t)
+
+	sut := NewBatchBuilder(3000000, false, 0)
+	a.NoError(sut.AddDelete(&TagDelete{Value: "old_value"}))
+
+	batchBytes, upsertCount, deleteCount, err := sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Equal(0, upsertCount)
+	a.Equal(1, deleteCount)
+	a.Equal(`{"guid":"","replace_all":false,"ttl_minutes":0,"upserts":[],"deletes":[{"value":"old_value"}],"complete":true}`, string(batchBytes))
+}
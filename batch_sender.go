@@ -0,0 +1,530 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// partSource builds the serialized parts of a batch - see BatchBuilder
+type partSource interface {
+	SetBatchGUID(guid string)
+	BuildBatchRequestCounts() ([]byte, int, int, error)
+	IsComplete() bool
+
+	lastPartItems() ([][]byte, [][]byte)
+	partHeader() partHeader
+	observeCompression(rawSize int, compressedSize int)
+	lowerDesiredSize(size int)
+}
+
+// outgoingPart is a built batch part, ready to send
+type outgoingPart struct {
+	index       int
+	guid        string      // batch GUID the part was built with - empty for the first part
+	raw         []byte      // uncompressed JSON - only kept for dry runs
+	rawSize     int         // size of the uncompressed JSON
+	body        []byte      // request body - compressed, if compression is enabled
+	pooledBody  *pooledBody // pooled buffer holding the body - released once the part's been sent
+	upserts     [][]byte
+	deletes     [][]byte
+	upsertCount int
+	deleteCount int
+	complete    bool
+}
+
+// itemCount returns how many upserts and deletes are in the part
+func (p *outgoingPart) itemCount() int {
+	return p.upsertCount + p.deleteCount
+}
+
+// batchSender sends the parts of one batch, tracking progress in a SendBatchResult
+type batchSender struct {
+	client      *Client
+	url         string
+	retryPolicy RetryPolicy
+	encoder     BodyEncoder
+	metrics     MetricsRecorder
+	log         Logger
+	concurrency int
+	sizing      PartSizing
+	maxBodySize int          // parts with bigger bodies are split before sending - zero doesn't check
+	header      partHeader   // for rebuilding parts when they're split
+	dryRun      DryRunSink   // optional - parts are written here instead of being sent
+	progress    ProgressFunc // optional - called as each part is accepted
+
+	checkpoints  CheckpointStore // optional - accepted parts are checkpointed here
+	checkpointID string
+
+	shrinkGuard *ShrinkGuard // optional - checked before the complete part of a replace-all batch is sent
+	shrinkCount func() int   // upserts in the batch, once it's been read
+	allowShrink bool
+
+	lock        sync.Mutex // protects the fields below, which are updated by concurrent sends
+	result      *SendBatchResult
+	err         error // first error - we stop sending once there is one
+	dryRunParts []DryRunPart
+	partCount   int // parts numbered so far, including the halves of split parts
+	partLimit   int // desired size of the parts still to be built, lowered after a 413 - zero leaves it alone
+}
+
+// newBatchSender builds a batchSender with the Client's current settings
+func (c *Client) newBatchSender(url string, result *SendBatchResult) *batchSender {
+	encoder := c.bodyEncoder()
+	metrics := c.metricsRecorder()
+	log := c.logger()
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	s := &batchSender{
+		client:      c,
+		url:         url,
+		retryPolicy: c.retryPolicy,
+		encoder:     encoder,
+		metrics:     metrics,
+		log:         log,
+		concurrency: c.partConcurrency,
+		sizing:      c.partSizing,
+		result:      result,
+	}
+	if s.concurrency < 1 {
+		s.concurrency = 1
+	}
+	if s.encoder.ContentEncoding() == "" {
+		// nothing to size by compressed size
+		s.sizing = PartSizeUncompressed
+	}
+	if s.sizing == PartSizeCompressed {
+		s.maxBodySize = c.OutgoingRequestSize
+	}
+	return s
+}
+
+// run builds and sends every part from the source, updating the result as parts are accepted.
+// - the first part is sent on its own, since the rest need the GUID in its response
+// - parts after that are sent concurrently, if the Client allows it
+// - the part that completes the batch is sent last, once all the others have been accepted
+func (s *batchSender) run(ctx context.Context, source partSource) error {
+	s.header = source.partHeader()
+
+	if s.dryRun == nil {
+		started := time.Now()
+		defer func() {
+			s.metrics.BatchDone(time.Since(started), s.firstError())
+		}()
+	}
+
+	// stop any in-flight parts once one fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	inFlight := sync.WaitGroup{}
+	slots := make(chan struct{}, s.concurrency)
+
+	for s.firstError() == nil {
+		index := s.nextPartIndex()
+		part, err := s.buildPart(source, index)
+		if err != nil {
+			s.fail(index, err, "%s", err)
+			break
+		}
+		if part == nil {
+			// sent the last part
+			break
+		}
+
+		if index == 0 || part.complete || s.concurrency == 1 {
+			inFlight.Wait()
+			if s.firstError() != nil {
+				break
+			}
+			if s.sendPart(ctx, part) {
+				s.checkpointPart(source, part)
+			}
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			s.fail(index, ctx.Err(), "Error sending batch part - [%s] - underlying error: %s", s.result, ctx.Err())
+			continue
+		}
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			defer func() { <-slots }()
+			if !s.sendPart(ctx, part) {
+				cancel()
+			}
+		}()
+	}
+
+	inFlight.Wait()
+	if err := s.firstError(); err != nil {
+		return err
+	}
+	s.recordShrinkCount()
+	return nil
+}
+
+// nextPartIndex numbers the next part - every part sent gets its own index, so dry run files and
+// results don't collide when parts are split
+func (s *batchSender) nextPartIndex() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	index := s.partCount
+	s.partCount++
+	return index
+}
+
+// buildPart builds the next part from the source, returning nil once the batch has been fully built
+func (s *batchSender) buildPart(source partSource, index int) (*outgoingPart, error) {
+	s.lock.Lock()
+	guid := s.result.BatchGUID
+	partLimit := s.partLimit
+	s.lock.Unlock()
+	source.SetBatchGUID(guid)
+	if partLimit > 0 {
+		source.lowerDesiredSize(partLimit)
+	}
+
+	requestBytes, _, _, err := source.BuildBatchRequestCounts()
+	if err != nil {
+		return nil, fmt.Errorf("Error building batch: %w", err)
+	}
+	if requestBytes == nil {
+		return nil, nil
+	}
+
+	upserts, deletes := source.lastPartItems()
+	part, err := s.newOutgoingPart(index, guid, requestBytes, upserts, deletes, source.IsComplete())
+	if err != nil {
+		return nil, err
+	}
+	if s.sizing != PartSizeUncompressed {
+		source.observeCompression(part.rawSize, len(part.body))
+	}
+	if part.complete {
+		if err := s.checkShrink(); err != nil {
+			part.pooledBody.release()
+			return nil, err
+		}
+	}
+	s.log.Debug("built batch part", "url", s.url, "part", index, "upserts", part.upsertCount, "deletes", part.deleteCount,
+		"raw_bytes", part.rawSize, "body_bytes", len(part.body), "complete", part.complete)
+	return part, nil
+}
+
+// rebuildPart builds a part from some of the upserts and deletes of another part, with the batch's current GUID
+func (s *batchSender) rebuildPart(index int, upserts [][]byte, deletes [][]byte, complete bool) (*outgoingPart, error) {
+	s.lock.Lock()
+	guid := s.result.BatchGUID
+	s.lock.Unlock()
+
+	requestBytes, err := serializeBatchPart(guid, s.header, upserts, deletes, complete)
+	if err != nil {
+		return nil, fmt.Errorf("Error building batch: %w", err)
+	}
+	return s.newOutgoingPart(index, guid, requestBytes, upserts, deletes, complete)
+}
+
+func (s *batchSender) newOutgoingPart(index int, guid string, requestBytes []byte, upserts [][]byte, deletes [][]byte, complete bool) (*outgoingPart, error) {
+	// encode the batch into a pooled buffer - even uncompressed, we need our own copy, since the source
+	// reuses its buffer
+	bodyBuf, err := encodeBody(s.encoder, requestBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Error encoding JSON request: %w", err)
+	}
+
+	var raw []byte
+	if s.dryRun != nil {
+		raw = append([]byte(nil), requestBytes...)
+	}
+
+	return &outgoingPart{
+		index:       index,
+		guid:        guid,
+		raw:         raw,
+		rawSize:     len(requestBytes),
+		body:        bodyBuf.Bytes(),
+		pooledBody:  newPooledBody(bodyBuf),
+		upserts:     upserts,
+		deletes:     deletes,
+		upsertCount: len(upserts),
+		deleteCount: len(deletes),
+		complete:    complete,
+	}, nil
+}
+
+// sendPart sends a part, updating the result if it's accepted, or recording the error if not.
+// Returns whether the part was accepted.
+func (s *batchSender) sendPart(ctx context.Context, part *outgoingPart) bool {
+	// requests hold the body until net/http closes it, which may be after they return
+	defer part.pooledBody.release()
+
+	if s.maxBodySize > 0 && len(part.body) > s.maxBodySize && part.itemCount() > 1 {
+		// compressed worse than expected
+		return s.sendSplitPart(ctx, part)
+	}
+	if s.dryRun != nil {
+		return s.sendDryRunPart(part)
+	}
+
+	firstPart := part.guid == ""
+	responseBytes, stats, err := s.client.postBatchPart(ctx, s.retryPolicy, s.url, part.pooledBody, s.encoder.ContentEncoding(), firstPart)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestEntityTooLarge && part.itemCount() > 1 {
+			// the server's limit is lower than we thought
+			s.lowerPartLimit(part)
+			return s.sendSplitPart(ctx, part)
+		}
+		message := ""
+		if apiErr != nil {
+			message = apiErr.Message
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.failPartLocked(part, stats, message, err, "Error POSTing populators to %s (%d bytes) - [%s] - underlying error: %s", s.url, len(part.body), s.result, err)
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// every part's response is checked, so a part the server rejected is never counted as sent
+	apiResponse := APIServerResponse{}
+	if err := json.Unmarshal(responseBytes, &apiResponse); err != nil {
+		s.failPartLocked(part, stats, "", err, "Error unmarshalling API batch response - [%s] - underlying error: %s", s.result, err)
+		return false
+	}
+	if apiResponse.Error != "" {
+		apiErr := &APIError{
+			StatusCode:  http.StatusOK,
+			Message:     apiResponse.Message,
+			ServerError: apiResponse.Error,
+			Body:        responseBytes,
+		}
+		s.failPartLocked(part, stats, apiResponse.Message, apiErr, "API response contained an error - [%s] - server message: %s; server error: %s", s.result, apiResponse.Message, apiResponse.Error)
+		return false
+	}
+
+	if firstPart {
+		// first response returns the batch GUID, which we need to include in subsequent batches
+		if apiResponse.GUID == "" {
+			s.failPartLocked(part, stats, apiResponse.Message, ErrMissingBatchGUID, "API response did not include a GUID for subsequent batches - [%s] - server message: %s; server error: %s", s.result, apiResponse.Message, apiResponse.Error)
+			return false
+		}
+		s.result.BatchGUID = apiResponse.GUID
+		s.log.Info("received batch GUID", "url", s.url, "guid", apiResponse.GUID)
+	} else if apiResponse.GUID != "" && apiResponse.GUID != part.guid {
+		// the server doesn't have to echo the GUID, but if it does, it has to be ours
+		s.failPartLocked(part, stats, apiResponse.Message, ErrBatchGUIDMismatch, "API response GUID %s did not match batch GUID - [%s] - server message: %s", apiResponse.GUID, s.result, apiResponse.Message)
+		return false
+	}
+
+	s.recordPartLocked(part, stats, apiResponse.Message)
+	return true
+}
+
+// failPartLocked records a part that failed in the result, as well as failing the batch
+// - parts cancelled because an earlier part failed aren't recorded, since they didn't fail themselves
+func (s *batchSender) failPartLocked(part *outgoingPart, stats postStats, message string, err error, format string, args ...interface{}) {
+	if s.err != nil && errors.Is(err, context.Canceled) {
+		return
+	}
+
+	s.result.Failures = append(s.result.Failures, PartFailure{
+		Index:       part.index,
+		UpsertCount: part.upsertCount,
+		DeleteCount: part.deleteCount,
+		Attempts:    stats.attempts,
+		Message:     message,
+		Err:         err,
+	})
+	s.failLocked(part.index, err, format, args...)
+}
+
+// recordPartLocked updates the result with an accepted part, and reports progress
+func (s *batchSender) recordPartLocked(part *outgoingPart, stats postStats, message string) {
+	partResult := PartResult{
+		Index:       part.index,
+		UpsertCount: part.upsertCount,
+		DeleteCount: part.deleteCount,
+		RawBytes:    part.rawSize,
+		BodyBytes:   len(part.body),
+		Attempts:    stats.attempts,
+		Latency:     stats.latency,
+		Message:     message,
+		Complete:    part.complete,
+	}
+
+	s.result.PartsSent++
+	s.result.UpsertsSent += part.upsertCount
+	s.result.DeletesSent += part.deleteCount
+	s.result.Parts = append(s.result.Parts, partResult)
+	if s.dryRun == nil {
+		s.metrics.PartSent(partResult.RawBytes, partResult.BodyBytes)
+		s.log.Debug("sent batch part", "url", s.url, "guid", s.result.BatchGUID, "part", part.index, "upserts", part.upsertCount,
+			"deletes", part.deleteCount, "attempts", stats.attempts, "latency", stats.latency, "complete", part.complete)
+	}
+
+	if s.progress != nil {
+		s.progress(partResult, *s.result)
+	}
+}
+
+// sendDryRunPart writes a part to the dry run sink, updating the result as if it had been sent
+func (s *batchSender) sendDryRunPart(part *outgoingPart) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.writeDryRunPart(part); err != nil {
+		s.failLocked(part.index, err, "Error writing dry run part - [%s] - underlying error: %s", s.result, err)
+		return false
+	}
+
+	if part.guid == "" {
+		s.result.BatchGUID = DRY_RUN_BATCH_GUID
+	}
+	s.recordPartLocked(part, postStats{}, "")
+	return true
+}
+
+// lowerPartLimit halves the size of the parts still to be built, after the server rejected a part as too big,
+// so the rest of the batch isn't rejected and split too
+func (s *batchSender) lowerPartLimit(part *outgoingPart) {
+	// the same size the source compares with its desired size
+	size := len(part.body)
+	if s.sizing == PartSizeUncompressed {
+		size = part.rawSize
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if limit := size / 2; limit > 0 && (s.partLimit == 0 || limit < s.partLimit) {
+		s.partLimit = limit
+		s.log.Warn("server rejected a batch part as too big, lowering the part size", "url", s.url, "part", part.index, "part_size", limit)
+	}
+}
+
+// sendSplitPart splits a part in two, sending each half in turn under the batch's GUID. Halves that are still
+// too big are split again. Only the second half completes the batch, if the part did.
+// - the first half takes over the part's index, since the part itself is never sent; the second half gets a new one
+func (s *batchSender) sendSplitPart(ctx context.Context, part *outgoingPart) bool {
+	half := part.itemCount() / 2
+	var firstUpserts, firstDeletes, secondUpserts, secondDeletes [][]byte
+	if half <= len(part.upserts) {
+		firstUpserts, secondUpserts = part.upserts[:half], part.upserts[half:]
+		secondDeletes = part.deletes
+	} else {
+		firstUpserts = part.upserts
+		firstDeletes, secondDeletes = part.deletes[:half-len(part.upserts)], part.deletes[half-len(part.upserts):]
+	}
+
+	first, err := s.rebuildPart(part.index, firstUpserts, firstDeletes, false)
+	if err != nil {
+		s.fail(part.index, err, "%s", err)
+		return false
+	}
+	if !s.sendPart(ctx, first) {
+		return false
+	}
+
+	// built after the first half is sent, since that may have returned the batch GUID
+	secondIndex := s.nextPartIndex()
+	second, err := s.rebuildPart(secondIndex, secondUpserts, secondDeletes, part.complete)
+	if err != nil {
+		s.fail(secondIndex, err, "%s", err)
+		return false
+	}
+	return s.sendPart(ctx, second)
+}
+
+func (s *batchSender) firstError() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.err
+}
+
+// fail records a failure of the given part, unless there's already been one
+// - the message is formatted under lock, so it may include the result
+func (s *batchSender) fail(partIndex int, err error, format string, args ...interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.failLocked(partIndex, err, format, args...)
+}
+
+func (s *batchSender) failLocked(partIndex int, err error, format string, args ...interface{}) {
+	if s.err == nil {
+		s.err = newBatchSendError(s.result, partIndex, err, format, args...)
+		s.log.Error("batch failed", "url", s.url, "guid", s.result.BatchGUID, "part", partIndex, "error", err)
+	}
+}
+
+// postStats describes how a part was POSTed
+type postStats struct {
+	attempts int
+	latency  time.Duration // of the last attempt, not counting time waiting for the rate limiter
+}
+
+// postBatchPart POSTs a batch part, retrying according to the retry policy.
+// - each attempt sends exactly the same bytes, so a retried part carries the same batch GUID and upserts
+func (c *Client) postBatchPart(ctx context.Context, policy RetryPolicy, url string, body *pooledBody, contentEncoding string, firstPart bool) ([]byte, postStats, error) {
+	stats := postStats{}
+	for attempt := 0; ; attempt++ {
+		req, err := c.newPooledAPIRequest("POST", url, body, contentEncoding)
+		if err != nil {
+			return nil, stats, err
+		}
+		// with every circuit breaker open, the attempt fails without waiting on the rate limiter
+		if err = c.checkCircuits(req); err == nil {
+			if err := c.waitForRateLimit(ctx, req); err != nil {
+				return nil, stats, err
+			}
+			started := time.Now()
+			var responseBytes []byte
+			responseBytes, err = c.roundTrip(ctx, req)
+			stats.attempts++
+			stats.latency = time.Since(started)
+			if err == nil {
+				return responseBytes, stats, nil
+			}
+		}
+
+		if attempt >= policy.MaxRetries {
+			if attempt > 0 {
+				return nil, stats, fmt.Errorf("gave up after %d attempts: %w", attempt+1, err)
+			}
+			return nil, stats, err
+		}
+		retry, minWait := policy.retryDecision(err, firstPart)
+		if !retry {
+			return nil, stats, err
+		}
+
+		wait := policy.backoff(attempt + 1)
+		if wait < minWait {
+			wait = minWait
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+			c.logger().Warn("throttled by server", "url", url, "attempt", attempt+1, "retry_after", apiErr.RetryAfter)
+		}
+		c.logger().Warn("retrying batch part", "url", url, "attempt", attempt+1, "wait", wait, "error", err)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, stats, err
+		}
+		c.metricsRecorder().Retry()
+	}
+}
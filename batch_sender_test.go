@@ -0,0 +1,241 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// build a batch with the given number of small upserts
+func buildTestBatch(upsertCount int, replaceAll bool) *TagBatchPart {
+	batch := &TagBatchPart{
+		ReplaceAll: replaceAll,
+		IsComplete: true,
+		Upserts:    make([]TagUpsert, 0, upsertCount),
+	}
+	for i := 0; i < upsertCount; i++ {
+		batch.Upserts = append(batch.Upserts, TagUpsert{
+			Value: fmt.Sprintf("test%03d", i),
+			Criteria: []TagCriteria{
+				{
+					Direction:   "src",
+					IPAddresses: []string{fmt.Sprintf("10.0.%d.%d", i/256, i%256)},
+				},
+			},
+		})
+	}
+	return batch
+}
+
+// concurrencyServer is a fake API server that tracks the batch parts it receives, and how many were in flight at once
+type concurrencyServer struct {
+	*httptest.Server
+
+	lock        sync.Mutex
+	parts       []TagBatchPart
+	inFlight    int
+	maxInFlight int
+	failPart    int // 1-based count of the request to fail - zero to never fail
+}
+
+func newConcurrencyServer(a *require.Assertions) *concurrencyServer {
+	ret := &concurrencyServer{}
+	ret.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonPayload := getJSON(a, r)
+		part := TagBatchPart{}
+		a.NoError(json.Unmarshal(jsonPayload, &part))
+
+		ret.lock.Lock()
+		ret.parts = append(ret.parts, part)
+		fail := len(ret.parts) == ret.failPart
+		ret.inFlight++
+		if ret.inFlight > ret.maxInFlight {
+			ret.maxInFlight = ret.inFlight
+		}
+		ret.lock.Unlock()
+
+		// give other parts a chance to overlap with this one
+		time.Sleep(20 * time.Millisecond)
+
+		ret.lock.Lock()
+		ret.inFlight--
+		ret.lock.Unlock()
+
+		if fail {
+			w.WriteHeader(500)
+			_, _ = w.Write([]byte("server error occurred"))
+			return
+		}
+		responseBytes, err := json.Marshal(&APIServerResponse{GUID: "c8285742-f7a4-4870-933d-665b15c31eda"})
+		a.NoError(err)
+		w.WriteHeader(200)
+		_, _ = w.Write(responseBytes)
+	}))
+	return ret
+}
+
+// receivedParts returns a copy of the parts received so far
+func (s *concurrencyServer) receivedParts() []TagBatchPart {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]TagBatchPart(nil), s.parts...)
+}
+
+// peakInFlight returns the most parts that were in flight at once
+func (s *concurrencyServer) peakInFlight() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.maxInFlight
+}
+
+func TestSendBatch_ConcurrentParts(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	sut, err := NewClient(WithPartConcurrency(4), WithMaxPartSize(200))
+	a.NoError(err)
+
+	response, err := sut.SendBatch(context.Background(), server.URL, buildTestBatch(40, true))
+	a.NoError(err)
+	a.Equal(40, response.UpsertsSent)
+	parts := server.receivedParts()
+	a.Equal(len(parts), response.PartsSent)
+	a.True(response.PartsSent > 10)
+
+	// parts overlapped, but never more than allowed
+	maxInFlight := server.peakInFlight()
+	a.True(maxInFlight > 1, maxInFlight)
+	a.True(maxInFlight <= 4, maxInFlight)
+
+	// first part has no GUID, and the complete part came in last
+	a.Equal("", parts[0].BatchGUID)
+	seen := make(map[string]bool)
+	for i, part := range parts {
+		if i > 0 {
+			a.Equal("c8285742-f7a4-4870-933d-665b15c31eda", part.BatchGUID)
+		}
+		a.Equal(i == len(parts)-1, part.IsComplete)
+		for _, upsert := range part.Upserts {
+			a.False(seen[upsert.Value])
+			seen[upsert.Value] = true
+		}
+	}
+	a.Equal(40, len(seen))
+}
+
+func TestSendBatch_ConcurrentPartsFailure(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	server.failPart = 3
+	defer server.Close()
+
+	sut, err := NewClient(WithPartConcurrency(4), WithMaxPartSize(200))
+	a.NoError(err)
+
+	response, err := sut.SendBatch(context.Background(), server.URL, buildTestBatch(40, true))
+	a.Error(err)
+
+	var sendErr *BatchSendError
+	a.True(errors.As(err, &sendErr))
+	a.True(sendErr.Part > 0)
+	a.Equal(response, sendErr.Result)
+	a.True(response.UpsertsSent < 40)
+
+	// the batch was never completed
+	for _, part := range server.receivedParts() {
+		a.False(part.IsComplete)
+	}
+}
+
+func TestSendBatch_VerifiesEveryPartResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string // response to the second part
+		expectedErr error  // optional
+		serverError string // optional - the error the server reported
+	}{
+		{"server error", `{"guid":"abc","message":"could not apply","error":"internal error"}`, nil, "internal error"},
+		{"guid mismatch", `{"guid":"def","message":"wrong batch"}`, ErrBatchGUIDMismatch, ""},
+		{"invalid response", `not json`, nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := require.New(t)
+
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 2 {
+					_, _ = w.Write([]byte(test.response))
+					return
+				}
+				_, _ = w.Write([]byte(`{"guid":"abc","message":"ok"}`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithMaxPartSize(300))
+			a.NoError(err)
+
+			result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(10, false))
+			a.Error(err)
+			if test.expectedErr != nil {
+				a.True(errors.Is(err, test.expectedErr))
+			}
+			if test.serverError != "" {
+				var apiErr *APIError
+				a.True(errors.As(err, &apiErr))
+				a.Equal(test.serverError, apiErr.ServerError)
+			}
+
+			// the failed part isn't counted as sent, but is reported as a failure
+			a.Equal(1, result.PartsSent)
+			a.Len(result.Parts, 1)
+			a.Equal("ok", result.Parts[0].Message)
+			a.Len(result.Failures, 1)
+			a.Equal(1, result.Failures[0].Index)
+			a.True(result.Failures[0].UpsertCount > 0)
+			a.Equal(1, result.Failures[0].Attempts)
+			a.Error(result.Failures[0].Err)
+
+			var sendErr *BatchSendError
+			a.True(errors.As(err, &sendErr))
+			a.Equal(1, sendErr.Part)
+		})
+	}
+}
+
+func TestSendBatch_CollectsPartMessages(t *testing.T) {
+	a := require.New(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"guid":"abc","message":"part %d"}`, requests)))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithMaxPartSize(300))
+	a.NoError(err)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(10, false))
+	a.NoError(err)
+	a.Empty(result.Failures)
+	a.True(len(result.Parts) > 2)
+	for i, part := range result.Parts {
+		a.Equal(fmt.Sprintf("part %d", i+1), part.Message)
+	}
+}
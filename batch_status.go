@@ -0,0 +1,117 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DEFAULT_BATCH_POLL_INTERVAL = 5 * time.Second
+)
+
+// BatchState is where the server is with processing a batch
+type BatchState string
+
+const (
+	BatchStatePending BatchState = "pending" // received, but not applied yet
+	BatchStateApplied BatchState = "applied"
+	BatchStateFailed  BatchState = "failed"
+)
+
+// BatchStatus is the server's status of a batch, after it's been sent
+type BatchStatus struct {
+	GUID           string
+	State          BatchState
+	Message        string          // message from the server, if it had one
+	Error          string          // why the batch failed, if it did
+	UpsertsApplied int             // how many upserts were applied
+	DeletesApplied int             // how many deletes were applied
+	ValueErrors    []APIValueError // values the server rejected - the rest of the batch may still have been applied
+}
+
+// Done returns whether the server has finished with the batch, whether or not it was applied
+func (s *BatchStatus) Done() bool {
+	return s.State != BatchStatePending
+}
+
+func (s *BatchStatus) String() string {
+	return fmt.Sprintf("Batch GUID: %s; State: %s; %d upserts, %d deletes applied; %d value errors", s.GUID, s.State, s.UpsertsApplied, s.DeletesApplied, len(s.ValueErrors))
+}
+
+// GetBatchStatus asks the server for the status of the batch with the given GUID, as returned in SendBatchResult
+func (c *Client) GetBatchStatus(ctx context.Context, guid string) (*BatchStatus, error) {
+	if guid == "" {
+		return nil, newValidationError("Batch GUID cannot be empty")
+	}
+
+	req, err := c.newAPIRequest("GET", c.Endpoints().BatchStatus(guid), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	apiResponse := APIServerResponse{}
+	if err := json.Unmarshal(res, &apiResponse); err != nil {
+		return nil, fmt.Errorf("Error unmarshaling batch status response: %s", err)
+	}
+	if apiResponse.Status == "" && apiResponse.Error != "" {
+		return nil, &APIError{StatusCode: 200, Message: apiResponse.Message, ServerError: apiResponse.Error, Body: res}
+	}
+
+	status := &BatchStatus{
+		GUID:           guid,
+		State:          BatchState(apiResponse.Status),
+		Message:        apiResponse.Message,
+		Error:          apiResponse.Error,
+		UpsertsApplied: int(apiResponse.UpsertsApplied),
+		DeletesApplied: int(apiResponse.DeletesApplied),
+		ValueErrors:    apiResponse.ValueErrors,
+	}
+	switch status.State {
+	case BatchStatePending, BatchStateApplied, BatchStateFailed:
+	case "":
+		// not processed yet
+		status.State = BatchStatePending
+	default:
+		return nil, fmt.Errorf("Unknown batch status '%s' for batch %s", apiResponse.Status, guid)
+	}
+	return status, nil
+}
+
+// WaitForBatch polls the status of the batch with the given GUID until the server has finished with it,
+// or the context is done. A failed batch isn't an error - check the returned status's State.
+//   - pollInterval defaults to DEFAULT_BATCH_POLL_INTERVAL if it isn't positive
+//   - polls that fail with an ErrRetryable error, such as a 5xx, a 429 or a transport error, are retried at the
+//     next interval; any other error is returned right away
+func (c *Client) WaitForBatch(ctx context.Context, guid string, pollInterval time.Duration) (*BatchStatus, error) {
+	if pollInterval <= 0 {
+		pollInterval = DEFAULT_BATCH_POLL_INTERVAL
+	}
+
+	var last *BatchStatus
+	for {
+		status, err := c.GetBatchStatus(ctx, guid)
+		switch {
+		case err == nil:
+			if status.Done() {
+				return status, nil
+			}
+			last = status
+		case ctx.Err() != nil:
+			return last, ctx.Err()
+		case errors.Is(err, ErrRetryable):
+			c.logger().Warn("error polling batch status, will retry", "guid", guid, "wait", pollInterval, "error", err)
+		default:
+			return nil, err
+		}
+		if err := sleepContext(ctx, pollInterval); err != nil {
+			return last, err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetBatchStatus(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("GET", r.Method)
+		a.Equal("/api/v5/batch/abc/status", r.URL.Path)
+		_, _ = w.Write([]byte(`{"guid":"abc","status":"applied","upserts_applied":9,"value_errors":[{"value":"bad","error":"invalid IP"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+
+	status, err := client.GetBatchStatus(context.Background(), "abc")
+	a.NoError(err)
+	a.Equal(BatchStateApplied, status.State)
+	a.True(status.Done())
+	a.Equal(9, status.UpsertsApplied)
+	a.Equal([]APIValueError{{Value: "bad", Error: "invalid IP"}}, status.ValueErrors)
+
+	_, err = client.GetBatchStatus(context.Background(), "")
+	a.True(errors.Is(err, ErrValidation))
+}
+
+func TestClient_WaitForBatch(t *testing.T) {
+	a := require.New(t)
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			_, _ = w.Write([]byte(`{"guid":"abc","status":"pending"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"guid":"abc","status":"failed","error":"too many values"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+
+	status, err := client.WaitForBatch(context.Background(), "abc", time.Millisecond)
+	a.NoError(err)
+	a.Equal(3, polls)
+	a.Equal(BatchStateFailed, status.State)
+	a.Equal("too many values", status.Error)
+}
+
+// Test that transient failures are polled through, and anything else stops the wait
+func TestClient_WaitForBatch_Errors(t *testing.T) {
+	a := require.New(t)
+
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[polls]
+		polls++
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"guid":"abc","status":"applied"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+
+	status, err := client.WaitForBatch(context.Background(), "abc", time.Millisecond)
+	a.NoError(err)
+	a.Equal(3, polls)
+	a.Equal(BatchStateApplied, status.State)
+
+	statuses = []int{http.StatusServiceUnavailable, http.StatusForbidden, http.StatusOK}
+	polls = 0
+	_, err = client.WaitForBatch(context.Background(), "abc", time.Millisecond)
+	a.True(errors.Is(err, ErrAuth))
+	a.Equal(2, polls)
+
+	// the server going away is retried until the context is done
+	server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForBatch(ctx, "abc", 5*time.Millisecond)
+	a.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestClient_WaitForBatch_Canceled(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForBatch(ctx, "abc", 5*time.Millisecond)
+	a.True(errors.Is(err, context.DeadlineExceeded))
+}
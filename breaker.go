@@ -0,0 +1,377 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_BREAKER_FAILURE_THRESHOLD = 5
+	DEFAULT_BREAKER_OPEN_TIMEOUT      = 30 * time.Second
+)
+
+// CircuitBreakerConfig configures the circuit breaker kept for each API base URL.
+// Once a base URL fails FailureThreshold times in a row, with connection errors or 5xx responses, its breaker
+// opens, and requests to it fail right away - or go to the next base URL, if there is one. After OpenTimeout,
+// one request probes the base URL with a lightweight GET first, closing the breaker if the probe succeeds.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // zero uses DEFAULT_BREAKER_FAILURE_THRESHOLD
+	OpenTimeout      time.Duration // zero uses DEFAULT_BREAKER_OPEN_TIMEOUT
+	Clock            Clock         // optional - only needed for testing
+}
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitState describes the circuit breaker of one API base URL
+type CircuitState struct {
+	BaseURL             string
+	State               string // CircuitClosed, CircuitOpen, or CircuitHalfOpen
+	ConsecutiveFailures int
+}
+
+// WithBaseURLs sets an ordered list of Kentik API base URLs - requests go to the first one whose circuit breaker
+// is closed, failing over to the next on connection errors. The first is the Client's BaseURL, which URLs
+// from Endpoints are built with. The base URLs must serve the same API, since a batch may fail over partway
+// through. Uses the default CircuitBreakerConfig, unless WithCircuitBreaker is also given.
+func WithBaseURLs(baseURLs ...string) Option {
+	return func(cfg *clientConfig) error {
+		if len(baseURLs) == 0 {
+			return fmt.Errorf("At least one base URL is needed")
+		}
+		normalized := make([]string, 0, len(baseURLs))
+		for _, baseURL := range baseURLs {
+			n, err := normalizeBaseURL(baseURL)
+			if err != nil {
+				return err
+			}
+			normalized = append(normalized, n)
+		}
+		cfg.client.baseURL = normalized[0]
+		cfg.failoverURLs = normalized[1:]
+		if cfg.breaker == nil {
+			cfg.breaker = &CircuitBreakerConfig{}
+		}
+		return nil
+	}
+}
+
+// WithCircuitBreaker sets up a circuit breaker for each API base URL - see CircuitBreakerConfig
+func WithCircuitBreaker(breakerConfig CircuitBreakerConfig) Option {
+	return func(cfg *clientConfig) error {
+		cfg.breaker = &breakerConfig
+		return nil
+	}
+}
+
+// CircuitStates returns the state of the circuit breaker for each API base URL, in failover order - nil if
+// the Client doesn't have circuit breakers
+func (c *Client) CircuitStates() []CircuitState {
+	if c.backends == nil {
+		return nil
+	}
+
+	ret := make([]CircuitState, 0, len(c.backends.backends))
+	for _, b := range c.backends.backends {
+		ret = append(ret, b.state())
+	}
+	return ret
+}
+
+// circuitOpenError is returned when no base URL's circuit breaker lets a request through
+type circuitOpenError struct {
+	baseURLs []string
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s", strings.Join(e.baseURLs, ", "))
+}
+
+// Is reports whether the error belongs to one of the error classes
+func (e *circuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen || target == ErrRetryable
+}
+
+// backendSet is the API base URLs a Client fails over between, each with its circuit breaker
+type backendSet struct {
+	backends []*backend
+
+	lock   sync.Mutex
+	active *backend // the base URL requests last went to, so failing over is only logged when it changes
+}
+
+func newBackendSet(baseURLs []string, cfg CircuitBreakerConfig) *backendSet {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DEFAULT_BREAKER_FAILURE_THRESHOLD
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DEFAULT_BREAKER_OPEN_TIMEOUT
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
+
+	set := &backendSet{}
+	for _, baseURL := range baseURLs {
+		set.backends = append(set.backends, &backend{baseURL: baseURL, cfg: cfg, status: CircuitClosed})
+	}
+	set.active = set.backends[0]
+	return set
+}
+
+// match returns the base URL the given URL is under, and the rest of the URL after it
+func (s *backendSet) match(url string) (*backend, string, bool) {
+	for _, b := range s.backends {
+		if !strings.HasPrefix(url, b.baseURL) {
+			continue
+		}
+		rest := url[len(b.baseURL):]
+		if rest == "" || rest[0] == '/' || rest[0] == '?' {
+			return b, rest, true
+		}
+	}
+	return nil, "", false
+}
+
+// activate records that a request is going to the given base URL, returning the base URL requests went to
+// before, or nil if it's the same one
+func (s *backendSet) activate(b *backend) *backend {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.active == b {
+		return nil
+	}
+	previous := s.active
+	s.active = b
+	return previous
+}
+
+// openError returns the error for a request that no base URL's breaker lets through
+func (s *backendSet) openError() error {
+	baseURLs := make([]string, 0, len(s.backends))
+	for _, b := range s.backends {
+		baseURLs = append(baseURLs, b.baseURL)
+	}
+	return &circuitOpenError{baseURLs: baseURLs}
+}
+
+// backend is an API base URL, and its circuit breaker
+type backend struct {
+	baseURL string
+	cfg     CircuitBreakerConfig
+
+	lock     sync.Mutex
+	status   string
+	failures int // in a row
+	openedAt time.Time
+}
+
+// allow returns whether a request may be sent to the base URL, and whether it needs to probe it first
+func (b *backend) allow() (bool, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.status {
+	case CircuitClosed:
+		return true, false
+	case CircuitOpen:
+		if b.cfg.Clock.Now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+			// this request gets to probe - the rest are turned away until it's done
+			b.status = CircuitHalfOpen
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// available returns whether allow would let a request through, without changing the breaker
+func (b *backend) available() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.status {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		return b.cfg.Clock.Now().Sub(b.openedAt) >= b.cfg.OpenTimeout
+	}
+	return false
+}
+
+// success records a successful request, closing the breaker, and returns whether it was open
+func (b *backend) success() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	wasOpen := b.status != CircuitClosed
+	b.status = CircuitClosed
+	b.failures = 0
+	return wasOpen
+}
+
+// failure records a failed request, and returns whether it opened the breaker
+func (b *backend) failure() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.failures++
+	if b.status == CircuitHalfOpen || (b.status == CircuitClosed && b.failures >= b.cfg.FailureThreshold) {
+		b.status = CircuitOpen
+		b.openedAt = b.cfg.Clock.Now()
+		return true
+	}
+	return false
+}
+
+func (b *backend) state() CircuitState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return CircuitState{BaseURL: b.baseURL, State: b.status, ConsecutiveFailures: b.failures}
+}
+
+// isBackendFailure returns whether the outcome of a request counts against the base URL's circuit breaker
+// - the caller's context ending, or an interceptor failing, says nothing about the base URL
+// - a TransportError is always the base URL's, even a timeout, since the caller's context was still going
+func isBackendFailure(err error) bool {
+	var transportErr *TransportError
+	var interceptErr *interceptorError
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &transportErr):
+		return true
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.As(err, &interceptErr):
+		return false
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// roundTripFailover makes the request against the first base URL whose breaker lets it through, failing over
+// to the next if it can't connect. Requests that aren't under one of the base URLs are made as-is.
+func (c *Client) roundTripFailover(ctx context.Context, req *http.Request, backends *backendSet) ([]byte, error) {
+	matched, rest, ok := backends.match(req.URL.String())
+	if !ok {
+		return c.roundTripOnce(ctx, req)
+	}
+
+	var lastErr error
+	for _, b := range backends.backends {
+		allowed, probe := b.allow()
+		if !allowed {
+			continue
+		}
+		if probe && !c.probeBackend(ctx, b) {
+			continue
+		}
+
+		attempt := req
+		if b != matched {
+			var err error
+			if attempt, err = rebaseRequest(req, b.baseURL+rest); err != nil {
+				return nil, err
+			}
+		}
+		if previous := backends.activate(b); previous != nil {
+			if b == backends.backends[0] {
+				c.logger().Info("failing back to the first base URL", "from", previous.baseURL, "to", b.baseURL)
+			} else {
+				c.logger().Warn("failing over to another base URL", "from", previous.baseURL, "to", b.baseURL)
+			}
+		}
+
+		responseBytes, err := c.roundTripOnce(ctx, attempt)
+		c.recordBackendOutcome(b, err)
+		if err == nil || !isConnectError(err) || ctx.Err() != nil {
+			// the server answered, or it may have - don't send it again
+			return responseBytes, err
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, backends.openError()
+}
+
+// checkCircuits fails fast if the request would go to a base URL, and every base URL's breaker is open, so
+// callers don't wait on the rate limiter for a request that won't be sent
+func (c *Client) checkCircuits(req *http.Request) error {
+	if c.backends == nil {
+		return nil
+	}
+	if _, _, ok := c.backends.match(req.URL.String()); !ok {
+		return nil
+	}
+	for _, b := range c.backends.backends {
+		if b.available() {
+			return nil
+		}
+	}
+	return c.backends.openError()
+}
+
+// probeBackend checks whether a base URL whose breaker was open is back, with a lightweight GET
+func (c *Client) probeBackend(ctx context.Context, b *backend) bool {
+	req, err := c.newAPIRequest("GET", Endpoints{baseURL: b.baseURL}.CustomDimensions(), nil, "")
+	if err != nil {
+		b.failure()
+		return false
+	}
+	_, err = c.roundTripOnce(ctx, req)
+	if ctx.Err() != nil {
+		// we don't know if it's back - leave it open
+		b.failure()
+		return false
+	}
+	c.recordBackendOutcome(b, err)
+	return !isBackendFailure(err)
+}
+
+func (c *Client) recordBackendOutcome(b *backend, err error) {
+	if !isBackendFailure(err) {
+		if b.success() {
+			c.logger().Info("circuit breaker closed", "base_url", b.baseURL)
+		}
+		return
+	}
+	if b.failure() {
+		c.logger().Warn("circuit breaker opened", "base_url", b.baseURL, "error", err)
+	}
+}
+
+// rebaseRequest copies a request, sending it to a different URL
+func rebaseRequest(req *http.Request, url string) (*http.Request, error) {
+	rebased, err := http.NewRequest(req.Method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	rebased.Header = req.Header.Clone()
+	rebased.ContentLength = req.ContentLength
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, fmt.Errorf("Cannot fail over a request whose body can't be re-read")
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		rebased.Body = body
+		rebased.GetBody = req.GetBody
+	}
+	return rebased, nil
+}
@@ -0,0 +1,253 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer answers with the given status, recording the paths requested
+type flakyServer struct {
+	*httptest.Server
+
+	lock   sync.Mutex
+	status int
+	paths  []string
+}
+
+func newFlakyServer(status int) *flakyServer {
+	s := &flakyServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.paths = append(s.paths, r.URL.Path)
+		w.WriteHeader(s.status)
+		_, _ = w.Write([]byte(`{"customDimensions":[],"guid":"abc"}`))
+	}))
+	return s
+}
+
+func (s *flakyServer) setStatus(status int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = status
+}
+
+func (s *flakyServer) requested() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+// deadURL returns a URL that refuses connections
+func deadURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func TestClient_FailoverOnConnectError(t *testing.T) {
+	a := require.New(t)
+
+	live := newFlakyServer(http.StatusOK)
+	defer live.Close()
+	dead := deadURL()
+
+	client, err := NewClient(WithBaseURLs(dead, live.URL))
+	a.NoError(err)
+	a.Equal(dead, client.BaseURL())
+
+	result, err := client.SendPopulators(context.Background(), "c_test", buildTestBatch(1, false))
+	a.NoError(err)
+	a.Equal("abc", result.BatchGUID)
+	a.Equal([]string{"/api/v5/batch/customdimensions/c_test/populators"}, live.requested())
+
+	states := client.CircuitStates()
+	a.Len(states, 2)
+	a.Equal(CircuitState{BaseURL: dead, State: CircuitClosed, ConsecutiveFailures: 1}, states[0])
+	a.Equal(CircuitState{BaseURL: live.URL, State: CircuitClosed}, states[1])
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	a := require.New(t)
+
+	server := newFlakyServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	clock := newFakeClock()
+	client, err := NewClient(WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, Clock: clock}))
+	a.NoError(err)
+
+	// two failures in a row open the breaker
+	for i := 0; i < 2; i++ {
+		_, err = client.ListDimensions(context.Background())
+		a.True(errors.Is(err, ErrRetryable))
+	}
+	a.Equal(CircuitOpen, client.CircuitStates()[0].State)
+
+	// after which requests fail without being sent
+	_, err = client.ListDimensions(context.Background())
+	a.True(errors.Is(err, ErrCircuitOpen))
+	a.True(errors.Is(err, ErrRetryable))
+	a.Len(server.requested(), 2)
+
+	// once it's been open a while, a probe that fails keeps it open
+	clock.Advance(time.Minute)
+	_, err = client.ListDimensions(context.Background())
+	a.True(errors.Is(err, ErrCircuitOpen))
+	a.Len(server.requested(), 3)
+	a.Equal(CircuitOpen, client.CircuitStates()[0].State)
+
+	// and a probe that succeeds closes it
+	server.setStatus(http.StatusOK)
+	clock.Advance(time.Minute)
+	_, err = client.ListDimensions(context.Background())
+	a.NoError(err)
+	a.Equal([]string{"/api/internal/customdimensions", "/api/internal/customdimensions"}, server.requested()[3:])
+	a.Equal(CircuitState{BaseURL: server.URL, State: CircuitClosed}, client.CircuitStates()[0])
+}
+
+func TestClient_CircuitBreakerFailover(t *testing.T) {
+	a := require.New(t)
+
+	primary := newFlakyServer(http.StatusInternalServerError)
+	defer primary.Close()
+	secondary := newFlakyServer(http.StatusOK)
+	defer secondary.Close()
+
+	client, err := NewClient(WithBaseURLs(primary.URL, secondary.URL),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Clock: newFakeClock()}))
+	a.NoError(err)
+
+	// the server answered, so the request isn't sent anywhere else
+	_, err = client.ListDimensions(context.Background())
+	a.Error(err)
+	a.Len(secondary.requested(), 0)
+
+	// but later requests go to the next base URL
+	_, err = client.ListDimensions(context.Background())
+	a.NoError(err)
+	a.Len(primary.requested(), 1)
+	a.Len(secondary.requested(), 1)
+
+	// URLs that aren't under the base URLs skip the breakers
+	_, err = client.EnsureDimensionSpecs(context.Background(), strings.Replace(primary.URL, "127.0.0.1", "localhost", 1), nil)
+	a.Error(err)
+	a.Len(primary.requested(), 2)
+}
+
+// Test that failing over is logged when requests switch base URLs, not for every request
+func TestClient_FailoverLoggedOnce(t *testing.T) {
+	a := require.New(t)
+
+	primary := newFlakyServer(http.StatusInternalServerError)
+	defer primary.Close()
+	secondary := newFlakyServer(http.StatusOK)
+	defer secondary.Close()
+
+	clock := newFakeClock()
+	log := &testLogger{}
+	client, err := NewClient(WithLogger(log), WithBaseURLs(primary.URL, secondary.URL),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, Clock: clock}))
+	a.NoError(err)
+
+	_, err = client.ListDimensions(context.Background())
+	a.Error(err)
+	for i := 0; i < 3; i++ {
+		_, err = client.ListDimensions(context.Background())
+		a.NoError(err)
+	}
+
+	// and failing back once the primary's back
+	primary.setStatus(http.StatusOK)
+	clock.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		_, err = client.ListDimensions(context.Background())
+		a.NoError(err)
+	}
+
+	failovers := make([]string, 0)
+	for _, msg := range log.messages() {
+		if strings.Contains(msg, "failing") {
+			failovers = append(failovers, msg)
+		}
+	}
+	a.Equal([]string{"warn: failing over to another base URL", "info: failing back to the first base URL"}, failovers)
+	a.Len(secondary.requested(), 3)
+}
+
+// countingLimiter counts the requests that waited on it
+type countingLimiter struct {
+	lock  sync.Mutex
+	waits int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context, bytes int) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.waits++
+	return nil
+}
+
+// Test that requests fail fast while every breaker is open, without taking a turn on the rate limiter
+func TestClient_CircuitOpenSkipsRateLimiter(t *testing.T) {
+	a := require.New(t)
+
+	server := newFlakyServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	client, err := NewClient(WithRateLimiter(limiter), WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, Clock: newFakeClock()}))
+	a.NoError(err)
+
+	_, err = client.ListDimensions(context.Background())
+	a.True(errors.Is(err, ErrRetryable))
+	a.Equal(1, limiter.waits)
+
+	_, err = client.ListDimensions(context.Background())
+	a.True(errors.Is(err, ErrCircuitOpen))
+	_, err = client.SendPopulators(context.Background(), "c_test", buildTestBatch(1, false))
+	a.True(errors.Is(err, ErrCircuitOpen))
+	a.Equal(1, limiter.waits)
+}
+
+// Test that the caller's own failures don't count against the base URL
+func TestClient_CircuitBreakerIgnoresCallerErrors(t *testing.T) {
+	a := require.New(t)
+
+	server := newFlakyServer(http.StatusOK)
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Clock: newFakeClock()}),
+		WithRequestInterceptor(func(req *http.Request) error {
+			if req.Header.Get("X-Fail") != "" {
+				return errors.New("refused")
+			}
+			return nil
+		}))
+	a.NoError(err)
+
+	req, err := client.newAPIRequest("GET", client.Endpoints().CustomDimensions(), nil, "")
+	a.NoError(err)
+	req.Header.Set("X-Fail", "1")
+	_, err = client.Do(context.Background(), req)
+	a.Error(err)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = client.ListDimensions(ctx)
+	a.True(errors.Is(err, context.DeadlineExceeded))
+
+	a.Equal(CircuitState{BaseURL: server.URL, State: CircuitClosed}, client.CircuitStates()[0])
+}
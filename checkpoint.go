@@ -0,0 +1,256 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint records how far a batch got, so an interrupted send can be finished under the same batch GUID.
+// It's saved after each part the server accepts, until the batch is complete.
+type Checkpoint struct {
+	ID           string             `json:"id"`
+	URL          string             `json:"url"`
+	BatchGUID    string             `json:"guid"`
+	ReplaceAll   bool               `json:"replace_all"`
+	TTLMinutes   uint32             `json:"ttl_minutes"`
+	Sender       TagBatchPartSender `json:"sender"`
+	PartsSent    int                `json:"parts_sent"` // parts the server has accepted
+	UpsertsSent  int                `json:"upserts_sent"`
+	UpsertsTotal int                `json:"upserts_total"`
+	DeletesSent  int                `json:"deletes_sent"`
+	DeletesTotal int                `json:"deletes_total"`
+	Upserts      []json.RawMessage  `json:"upserts"` // serialized upserts not yet accepted
+	Deletes      []json.RawMessage  `json:"deletes"` // serialized deletes not yet accepted
+	AllowShrink  bool               `json:"allow_shrink,omitempty"`
+	Updated      time.Time          `json:"updated"`
+}
+
+// CheckpointStore saves batch checkpoints - see FileCheckpointStore
+type CheckpointStore interface {
+	// Save saves the checkpoint, replacing any with the same ID
+	Save(checkpoint *Checkpoint) error
+
+	// Load returns the checkpoint with the given ID, or ErrNotFound
+	Load(id string) (*Checkpoint, error)
+
+	// Delete deletes the checkpoint with the given ID, if there is one
+	Delete(id string) error
+}
+
+// checkpointSource is a partSource whose unsent upserts and deletes can be checkpointed - streamed batches can't be
+type checkpointSource interface {
+	pendingItems() ([][]byte, [][]byte)
+}
+
+// SetCheckpointStore sets where batches are checkpointed as they're sent - nil doesn't checkpoint.
+// Only batches sent with a SendBatchOptions.CheckpointID are checkpointed, since two batches sent at once
+// need IDs that tell their checkpoints apart. Checkpointed batches send one part at a time, so the checkpoint
+// always covers every accepted part.
+// A part that was in flight when the process died is sent again on resume, which is harmless, since
+// upserts and deletes are idempotent within a batch.
+func (c *Client) SetCheckpointStore(store CheckpointStore) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.checkpoints = store
+}
+
+// WithCheckpointStore sets where batches are checkpointed as they're sent - see Client.SetCheckpointStore
+func WithCheckpointStore(store CheckpointStore) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.checkpoints = store
+		return nil
+	}
+}
+
+// ResumeBatch finishes sending a checkpointed batch under its original GUID, continuing to checkpoint it
+// if the Client has a CheckpointStore. The result counts the parts accepted before the checkpoint too.
+// A replace-all batch is checked against the Client's ShrinkGuard like any other, unless it was sent with AllowShrink.
+func (c *Client) ResumeBatch(ctx context.Context, checkpoint *Checkpoint) (*SendBatchResult, error) {
+	if checkpoint.BatchGUID == "" {
+		return nil, fmt.Errorf("Cannot resume a batch without a batch GUID")
+	}
+
+	c.lock.RLock()
+	sizing := c.partSizing
+	c.lock.RUnlock()
+
+	batchBuilder := NewBatchBuilder(c.OutgoingRequestSize, checkpoint.ReplaceAll, checkpoint.TTLMinutes)
+	batchBuilder.SetSenderInfo(checkpoint.Sender)
+	for _, upsert := range checkpoint.Upserts {
+		batchBuilder.serializedUpserts = append(batchBuilder.serializedUpserts, []byte(upsert))
+	}
+	for _, del := range checkpoint.Deletes {
+		batchBuilder.serializedDeletes = append(batchBuilder.serializedDeletes, []byte(del))
+	}
+	// the checkpointed upserts are already sorted, and we're past the first part
+	batchBuilder.builtBatchesCount = checkpoint.PartsSent
+	if encoder := c.bodyEncoder(); sizing != PartSizeUncompressed && encoder.ContentEncoding() != "" {
+		batchBuilder.estimateCompression(encoder)
+	}
+
+	ret := &SendBatchResult{
+		PartsSent:    checkpoint.PartsSent,
+		UpsertsSent:  checkpoint.UpsertsSent,
+		UpsertsTotal: checkpoint.UpsertsTotal,
+		DeletesSent:  checkpoint.DeletesSent,
+		DeletesTotal: checkpoint.DeletesTotal,
+		BatchGUID:    checkpoint.BatchGUID,
+	}
+
+	s := c.newBatchSender(checkpoint.URL, ret)
+	s.enableCheckpoints(checkpoint.ID)
+	s.guardShrink(checkpoint.ReplaceAll, checkpoint.AllowShrink, func() int { return checkpoint.UpsertsTotal })
+	err := s.run(ctx, batchBuilder)
+	return ret, err
+}
+
+// enableCheckpoints checkpoints the batch under the given ID, if there is one, and the Client has a CheckpointStore
+func (s *batchSender) enableCheckpoints(id string) {
+	s.client.lock.RLock()
+	store := s.client.checkpoints
+	s.client.lock.RUnlock()
+
+	if store == nil || id == "" {
+		return
+	}
+	s.checkpoints = store
+	s.checkpointID = id
+	s.concurrency = 1
+}
+
+// checkpointPart saves a checkpoint after a part is accepted, or deletes it once the batch is complete.
+// Failing to checkpoint doesn't stop the batch - it only means it can't be resumed.
+func (s *batchSender) checkpointPart(source partSource, part *outgoingPart) {
+	if s.checkpoints == nil {
+		return
+	}
+
+	if part.complete {
+		if err := s.checkpoints.Delete(s.checkpointID); err != nil {
+			s.log.Warn("error deleting batch checkpoint", "id", s.checkpointID, "error", err)
+		}
+		return
+	}
+
+	pending, ok := source.(checkpointSource)
+	if !ok {
+		return
+	}
+	upserts, deletes := pending.pendingItems()
+
+	s.lock.Lock()
+	checkpoint := &Checkpoint{
+		ID:           s.checkpointID,
+		URL:          s.url,
+		BatchGUID:    s.result.BatchGUID,
+		ReplaceAll:   s.header.replaceAll,
+		TTLMinutes:   s.header.ttlMinutes,
+		Sender:       s.header.sender,
+		PartsSent:    s.result.PartsSent,
+		UpsertsSent:  s.result.UpsertsSent,
+		UpsertsTotal: s.result.UpsertsTotal,
+		DeletesSent:  s.result.DeletesSent,
+		DeletesTotal: s.result.DeletesTotal,
+		Upserts:      make([]json.RawMessage, 0, len(upserts)),
+		Deletes:      make([]json.RawMessage, 0, len(deletes)),
+		AllowShrink:  s.allowShrink,
+		Updated:      time.Now(),
+	}
+	s.lock.Unlock()
+
+	for _, upsert := range upserts {
+		checkpoint.Upserts = append(checkpoint.Upserts, upsert)
+	}
+	for _, del := range deletes {
+		checkpoint.Deletes = append(checkpoint.Deletes, del)
+	}
+
+	if err := s.checkpoints.Save(checkpoint); err != nil {
+		s.log.Warn("error saving batch checkpoint", "id", s.checkpointID, "error", err)
+	}
+}
+
+// FileCheckpointStore saves each checkpoint as a JSON file in a directory
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore builds a FileCheckpointStore, creating the directory if needed
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating checkpoint directory: %s", err)
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+// Save writes the checkpoint to a temporary file, then renames it over the old one, so a crash while saving
+// leaves the previous checkpoint intact
+func (s *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	checkpointBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("Error marshalling checkpoint: %s", err)
+	}
+	if err := writeFileAtomic(s.path(checkpoint.ID), checkpointBytes); err != nil {
+		return fmt.Errorf("Error writing checkpoint file: %s", err)
+	}
+	return nil
+}
+
+// Load reads the checkpoint with the given ID
+func (s *FileCheckpointStore) Load(id string) (*Checkpoint, error) {
+	checkpointBytes, err := ioutil.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading checkpoint file: %s", err)
+	}
+
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(checkpointBytes, checkpoint); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling checkpoint: %s", err)
+	}
+	return checkpoint, nil
+}
+
+// Delete deletes the checkpoint with the given ID, if there is one
+func (s *FileCheckpointStore) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error deleting checkpoint file: %s", err)
+	}
+	return nil
+}
+
+// path returns the checkpoint's file - IDs are escaped, since they may be URLs or paths
+func (s *FileCheckpointStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// writeFileAtomic writes a file by writing a temporary file in the same directory, then renaming it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once it's been renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,154 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ResumeBatch(t *testing.T) {
+	a := require.New(t)
+
+	// the server accepts two parts, then goes away
+	received := make(map[string]int)
+	guids := make([]string, 0)
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		part := TagBatchPart{}
+		a.NoError(json.Unmarshal(getJSON(a, r), &part))
+		if fail && len(guids) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		guids = append(guids, part.BatchGUID)
+		for _, upsert := range part.Upserts {
+			received[upsert.Value]++
+		}
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	store, err := NewFileCheckpointStore(t.TempDir())
+	a.NoError(err)
+	client, err := NewClient(WithMaxPartSize(300), WithPartConcurrency(4), WithCheckpointStore(store))
+	a.NoError(err)
+
+	batch := buildTestBatch(10, true)
+	result, err := client.SendBatchWithOptions(context.Background(), server.URL, batch, SendBatchOptions{CheckpointID: "c_test"})
+	a.Error(err)
+	a.Equal(2, result.PartsSent)
+
+	checkpoint, err := store.Load("c_test")
+	a.NoError(err)
+	a.Equal("abc", checkpoint.BatchGUID)
+	a.Equal(server.URL, checkpoint.URL)
+	a.True(checkpoint.ReplaceAll)
+	a.Equal(2, checkpoint.PartsSent)
+	a.Equal(result.UpsertsSent, checkpoint.UpsertsSent)
+	a.Equal(10, checkpoint.UpsertsTotal)
+	a.Len(checkpoint.Upserts, 10-result.UpsertsSent)
+
+	// resume where we left off
+	fail = false
+	result, err = client.ResumeBatch(context.Background(), checkpoint)
+	a.NoError(err)
+	a.Equal(10, result.UpsertsSent)
+	a.Equal(10, result.UpsertsTotal)
+	a.True(result.PartsSent > 3)
+	a.Equal("abc", result.BatchGUID)
+
+	// every upsert was sent once, and every part after the first carried the GUID
+	a.Len(received, 10)
+	for value, count := range received {
+		a.Equal(1, count, value)
+	}
+	a.Equal("", guids[0])
+	for _, guid := range guids[1:] {
+		a.Equal("abc", guid)
+	}
+
+	// the checkpoint goes once the batch is complete
+	_, err = store.Load("c_test")
+	a.True(errors.Is(err, ErrNotFound))
+}
+
+// Test that batches sent without a checkpoint ID aren't checkpointed, so batches to the same URL can't
+// overwrite each other's checkpoints
+func TestClient_CheckpointNeedsID(t *testing.T) {
+	a := require.New(t)
+
+	// the server accepts the first part, then goes away
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := NewFileCheckpointStore(dir)
+	a.NoError(err)
+	client, err := NewClient(WithMaxPartSize(300), WithCheckpointStore(store))
+	a.NoError(err)
+
+	_, err = client.SendBatch(context.Background(), server.URL, buildTestBatch(10, true))
+	a.Error(err)
+	files, err := ioutil.ReadDir(dir)
+	a.NoError(err)
+	a.Empty(files)
+}
+
+func TestClient_ResumeBatchWithoutGUID(t *testing.T) {
+	a := require.New(t)
+
+	client, err := NewClient()
+	a.NoError(err)
+	_, err = client.ResumeBatch(context.Background(), &Checkpoint{URL: "http://localhost"})
+	a.Error(err)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	a := require.New(t)
+
+	dir := t.TempDir()
+	store, err := NewFileCheckpointStore(dir)
+	a.NoError(err)
+
+	_, err = store.Load("https://api.kentik.com/api/v5/batch/tags")
+	a.True(errors.Is(err, ErrNotFound))
+
+	checkpoint := &Checkpoint{
+		ID:        "https://api.kentik.com/api/v5/batch/tags",
+		BatchGUID: "abc",
+		Upserts:   []json.RawMessage{json.RawMessage(`{"value":"a"}`)},
+	}
+	a.NoError(store.Save(checkpoint))
+	checkpoint.PartsSent = 1
+	a.NoError(store.Save(checkpoint))
+
+	loaded, err := store.Load(checkpoint.ID)
+	a.NoError(err)
+	a.Equal(1, loaded.PartsSent)
+	a.Equal(`{"value":"a"}`, string(loaded.Upserts[0]))
+
+	// one file, with no temporary files left behind
+	files, err := ioutil.ReadDir(dir)
+	a.NoError(err)
+	a.Len(files, 1)
+
+	a.NoError(store.Delete(checkpoint.ID))
+	a.NoError(store.Delete(checkpoint.ID))
+	_, err = store.Load(checkpoint.ID)
+	a.True(errors.Is(err, ErrNotFound))
+}
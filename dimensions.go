@@ -0,0 +1,244 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	DimensionTypeString = "string"
+	DimensionTypeUint32 = "uint32"
+)
+
+// DimensionSpec describes a custom dimension that EnsureDimensionSpecs should make sure exists
+type DimensionSpec struct {
+	Name        string
+	DisplayName string
+	Type        string // DimensionTypeString or DimensionTypeUint32 - defaults to DimensionTypeString
+}
+
+// EnsureDimensionsReport reports what happened to each dimension passed to EnsureDimensionSpecs
+type EnsureDimensionsReport struct {
+	Created  []string         // names of the dimensions that were created
+	Existing []string         // names of the dimensions that already existed
+	Failed   map[string]error // why each dimension that couldn't be created failed, by name
+}
+
+// customDimensionResponse is the server's response to requests for a single custom dimension
+type customDimensionResponse struct {
+	Dimension *CustomDimension `json:"customDimension"`
+}
+
+// ListDimensions lists the company's custom dimensions
+func (c *Client) ListDimensions(ctx context.Context) ([]*CustomDimension, error) {
+	return c.listDimensions(ctx, c.Endpoints())
+}
+
+func (c *Client) listDimensions(ctx context.Context, endpoints Endpoints) ([]*CustomDimension, error) {
+	var currentSet CustomDimensionList
+	if err := c.doJSON(ctx, "GET", endpoints.CustomDimensions(), nil, &currentSet); err != nil {
+		return nil, err
+	}
+	return currentSet.Dimensions, nil
+}
+
+// GetDimension gets the custom dimension with the given ID
+// - returns an error matching ErrNotFound if there's no such dimension
+func (c *Client) GetDimension(ctx context.Context, id int) (*CustomDimension, error) {
+	response := customDimensionResponse{}
+	if err := c.doJSON(ctx, "GET", c.Endpoints().Dimension(id), nil, &response); err != nil {
+		return nil, err
+	}
+	if response.Dimension == nil {
+		return nil, fmt.Errorf("API response did not include custom dimension %d", id)
+	}
+	return response.Dimension, nil
+}
+
+// GetDimensionByName gets the custom dimension with the given name
+// - returns an error matching ErrNotFound if there's no such dimension
+func (c *Client) GetDimensionByName(ctx context.Context, name string) (*CustomDimension, error) {
+	dimensions, err := c.ListDimensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, dim := range dimensions {
+		if dim.Name == name {
+			return dim, nil
+		}
+	}
+	return nil, fmt.Errorf("Custom dimension '%s': %w", name, ErrNotFound)
+}
+
+// CreateDimension creates a custom dimension from the given name, display name and type, returning it
+// as the server created it
+func (c *Client) CreateDimension(ctx context.Context, spec DimensionSpec) (*CustomDimension, error) {
+	if err := ValidateDimensionName(spec.Name); err != nil {
+		return nil, err
+	}
+	return c.createDimension(ctx, c.Endpoints(), spec)
+}
+
+func (c *Client) createDimension(ctx context.Context, endpoints Endpoints, spec DimensionSpec) (*CustomDimension, error) {
+	cd := CustomDimension{
+		DisplayName: spec.DisplayName,
+		Name:        spec.Name,
+		Type:        spec.Type,
+		IsBulk:      true,
+		IsInternal:  strings.HasPrefix(spec.Name, "kt_"),
+	}
+	switch cd.Type {
+	case "":
+		cd.Type = DimensionTypeString
+	case DimensionTypeString, DimensionTypeUint32:
+	default:
+		return nil, newValidationError("Invalid dimension type '%s' for '%s': must be '%s' or '%s'", spec.Type, spec.Name, DimensionTypeString, DimensionTypeUint32)
+	}
+
+	response := customDimensionResponse{}
+	if err := c.doJSON(ctx, "POST", endpoints.CustomDimension(), cd, &response); err != nil {
+		return nil, err
+	}
+	if response.Dimension == nil {
+		// not every version of the API returns the new dimension
+		return &cd, nil
+	}
+	return response.Dimension, nil
+}
+
+// UpdateDimensionDisplayName changes the display name of the custom dimension with the given ID
+func (c *Client) UpdateDimensionDisplayName(ctx context.Context, id int, displayName string) (*CustomDimension, error) {
+	if displayName == "" {
+		return nil, newValidationError("Display name cannot be empty")
+	}
+
+	update := struct {
+		DisplayName string `json:"display_name"`
+	}{DisplayName: displayName}
+	response := customDimensionResponse{}
+	if err := c.doJSON(ctx, "PUT", c.Endpoints().Dimension(id), update, &response); err != nil {
+		return nil, err
+	}
+	if response.Dimension == nil {
+		return nil, fmt.Errorf("API response did not include custom dimension %d", id)
+	}
+	return response.Dimension, nil
+}
+
+// DeleteDimension deletes the custom dimension with the given ID, along with all of its populators.
+// Since that can't be undone, force must be true, or nothing is deleted.
+func (c *Client) DeleteDimension(ctx context.Context, id int, force bool) error {
+	if !force {
+		return newValidationError("Not deleting custom dimension %d: deleting a dimension deletes all of its populators, and needs force", id)
+	}
+	return c.doJSON(ctx, "DELETE", c.Endpoints().Dimension(id), nil, nil)
+}
+
+// EnsureDimensionSpecs creates any of the given dimensions which are not present for the company, carrying on
+// past failures. The report says what happened to each dimension, and an error is returned if any failed.
+// - apiHost is the base URL of the API - the Client's base URL is used if it's empty
+func (c *Client) EnsureDimensionSpecs(ctx context.Context, apiHost string, required []DimensionSpec) (*EnsureDimensionsReport, error) {
+	report := &EnsureDimensionsReport{
+		Created:  make([]string, 0),
+		Existing: make([]string, 0),
+		Failed:   make(map[string]error),
+	}
+
+	endpoints := c.Endpoints()
+	if apiHost != "" {
+		var err error
+		if endpoints, err = NewEndpoints(apiHost); err != nil {
+			return report, err
+		}
+	}
+
+	current, err := c.listDimensions(ctx, endpoints)
+	if err != nil {
+		return report, err
+	}
+	found := make(map[string]bool)
+	for _, dim := range current {
+		found[dim.Name] = true
+	}
+
+	// create them in name order, so the report's stable
+	sorted := append([]DimensionSpec(nil), required...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	log := c.logger()
+	var firstErr error
+	for _, spec := range sorted {
+		if found[spec.Name] {
+			report.Existing = append(report.Existing, spec.Name)
+			continue
+		}
+
+		if _, err := c.createDimension(ctx, endpoints, spec); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.alreadyInUse() {
+				// The column already exists. This can happen for columns that don't have a 'c_' prefix, such as 'kt_' columns.
+				// We get into this situation because our API call to get existing columns doesn't return these columns,
+				// so we're here trying to create them.
+				log.Info("custom dimension already in use", "name", spec.Name)
+				report.Existing = append(report.Existing, spec.Name)
+				continue
+			}
+			log.Warn("error creating custom dimension", "name", spec.Name, "error", err)
+			report.Failed[spec.Name] = err
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		log.Info("created custom dimension", "name", spec.Name, "type", spec.Type)
+		report.Created = append(report.Created, spec.Name)
+	}
+
+	c.metricsRecorder().DimensionsEnsured(len(report.Created), len(report.Existing))
+	if len(report.Failed) > 0 {
+		return report, fmt.Errorf("Error creating %d of %d custom dimensions, first error: %w", len(report.Failed), len(required), firstErr)
+	}
+	return report, nil
+}
+
+// doJSON sends a request with an optional JSON body, and unmarshals the JSON response into response, if
+// it's not nil and the server returned a body
+func (c *Client) doJSON(ctx context.Context, method string, url string, body interface{}, response interface{}) error {
+	encoder := c.bodyEncoder()
+	var req *http.Request
+	if body != nil {
+		jsonBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyBuf, err := encodeBody(encoder, jsonBytes)
+		if err != nil {
+			return fmt.Errorf("Error encoding JSON request: %s", err)
+		}
+		pooled := newPooledBody(bodyBuf)
+		defer pooled.release()
+		if req, err = c.newPooledAPIRequest(method, url, pooled, encoder.ContentEncoding()); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if req, err = c.newAPIRequest(method, url, nil, encoder.ContentEncoding()); err != nil {
+			return err
+		}
+	}
+	res, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	if response == nil || len(res) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res, response)
+}
@@ -0,0 +1,147 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dimensionServer is a fake API server that manages custom dimensions
+type dimensionServer struct {
+	*httptest.Server
+
+	lock       sync.Mutex
+	dimensions map[int]*CustomDimension
+	nextID     int
+}
+
+func newDimensionServer(a *require.Assertions, existing ...*CustomDimension) *dimensionServer {
+	s := &dimensionServer{
+		dimensions: make(map[int]*CustomDimension),
+		nextID:     1000,
+	}
+	for _, dim := range existing {
+		s.dimensions[dim.ID] = dim
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if r.URL.Path == "/api/internal/customdimensions" {
+			list := CustomDimensionList{Dimensions: make([]*CustomDimension, 0)}
+			for _, dim := range s.dimensions {
+				list.Dimensions = append(list.Dimensions, dim)
+			}
+			a.NoError(json.NewEncoder(w).Encode(list))
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/api/internal/customdimension" {
+			dim := CustomDimension{}
+			a.NoError(json.Unmarshal(getJSON(a, r), &dim))
+			if strings.HasPrefix(dim.Name, "c_fail") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"bad dimension"}`))
+				return
+			}
+			dim.ID = s.nextID
+			s.nextID++
+			s.dimensions[dim.ID] = &dim
+			a.NoError(json.NewEncoder(w).Encode(customDimensionResponse{Dimension: &dim}))
+			return
+		}
+
+		id := 0
+		if _, err := fmt.Sscanf(r.URL.Path, "/api/internal/customdimension/%d", &id); err != nil || s.dimensions[id] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			a.NoError(json.NewEncoder(w).Encode(customDimensionResponse{Dimension: s.dimensions[id]}))
+		case "PUT":
+			update := CustomDimension{}
+			a.NoError(json.Unmarshal(getJSON(a, r), &update))
+			s.dimensions[id].DisplayName = update.DisplayName
+			a.NoError(json.NewEncoder(w).Encode(customDimensionResponse{Dimension: s.dimensions[id]}))
+		case "DELETE":
+			_, _ = ioutil.ReadAll(r.Body)
+			delete(s.dimensions, id)
+		}
+	}))
+	return s
+}
+
+func TestClient_DimensionManagement(t *testing.T) {
+	a := require.New(t)
+
+	server := newDimensionServer(a, &CustomDimension{ID: 1, Name: "c_existing", DisplayName: "Existing", Type: DimensionTypeString})
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+	ctx := context.Background()
+
+	dim, err := client.GetDimensionByName(ctx, "c_existing")
+	a.NoError(err)
+	a.Equal(1, dim.ID)
+	_, err = client.GetDimensionByName(ctx, "c_missing")
+	a.True(errors.Is(err, ErrNotFound))
+
+	created, err := client.CreateDimension(ctx, DimensionSpec{Name: "c_count", DisplayName: "Count", Type: DimensionTypeUint32})
+	a.NoError(err)
+	a.Equal(DimensionTypeUint32, created.Type)
+	_, err = client.CreateDimension(ctx, DimensionSpec{Name: "c_bad_type", Type: "float"})
+	a.True(errors.Is(err, ErrValidation))
+
+	dim, err = client.UpdateDimensionDisplayName(ctx, created.ID, "Counter")
+	a.NoError(err)
+	a.Equal("Counter", dim.DisplayName)
+
+	dim, err = client.GetDimension(ctx, created.ID)
+	a.NoError(err)
+	a.Equal("Counter", dim.DisplayName)
+
+	// deleting needs force
+	err = client.DeleteDimension(ctx, created.ID, false)
+	a.True(errors.Is(err, ErrValidation))
+	a.NoError(client.DeleteDimension(ctx, created.ID, true))
+	_, err = client.GetDimension(ctx, created.ID)
+	a.True(errors.Is(err, ErrNotFound))
+
+	dimensions, err := client.ListDimensions(ctx)
+	a.NoError(err)
+	a.Equal(1, len(dimensions))
+}
+
+func TestClient_EnsureDimensionSpecs(t *testing.T) {
+	a := require.New(t)
+
+	server := newDimensionServer(a, &CustomDimension{ID: 1, Name: "c_existing", Type: DimensionTypeString})
+	defer server.Close()
+
+	client, err := NewClient()
+	a.NoError(err)
+
+	report, err := client.EnsureDimensionSpecs(context.Background(), server.URL, []DimensionSpec{
+		{Name: "c_new", DisplayName: "New", Type: DimensionTypeUint32},
+		{Name: "c_fail", DisplayName: "Fail"},
+		{Name: "c_existing", DisplayName: "Existing"},
+	})
+	a.Error(err)
+	a.True(errors.Is(err, ErrValidation))
+	a.Equal([]string{"c_new"}, report.Created)
+	a.Equal([]string{"c_existing"}, report.Existing)
+	a.Equal(1, len(report.Failed))
+	a.Error(report.Failed["c_fail"])
+}
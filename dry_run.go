@@ -0,0 +1,175 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// DRY_RUN_BATCH_GUID stands in for the batch GUID the server would have returned
+	DRY_RUN_BATCH_GUID = "00000000-0000-0000-0000-000000000000"
+)
+
+// DryRunPart describes a batch part built during a dry run
+type DryRunPart struct {
+	Index       int    `json:"index"`
+	FileName    string `json:"file_name,omitempty"` // set by sinks that write parts to files
+	UpsertCount int    `json:"upserts"`
+	DeleteCount int    `json:"deletes"`
+	RawBytes    int    `json:"raw_bytes"`                  // size of the JSON
+	BodyBytes   int    `json:"body_bytes"`                 // size of the request body that would have been sent
+	Encoding    string `json:"content_encoding,omitempty"` // Content-Encoding of the body - empty if it's uncompressed
+	Complete    bool   `json:"complete"`
+}
+
+// DryRunManifest describes a whole batch built during a dry run
+type DryRunManifest struct {
+	URL          string       `json:"url"`
+	ReplaceAll   bool         `json:"replace_all"`
+	TTLMinutes   uint32       `json:"ttl_minutes"`
+	UpsertsTotal int          `json:"upserts_total"`
+	DeletesTotal int          `json:"deletes_total"`
+	Parts        []DryRunPart `json:"parts"`
+}
+
+// DryRunSink receives the parts of a dry-run batch, instead of the server
+type DryRunSink interface {
+	// WritePart writes a part - raw is the part's JSON, and body is exactly what would have been POSTed.
+	// The sink may set the part's FileName, which is kept in the manifest. raw and body are only valid
+	// during the call.
+	WritePart(part *DryRunPart, raw []byte, body []byte) error
+
+	// WriteManifest is called once every part of the batch has been written
+	WriteManifest(manifest *DryRunManifest) error
+}
+
+// DryRunBatch runs a batch through the same pipeline as SendBatch, but writes each part to the sink
+// instead of sending it. Nothing goes over the network.
+// - parts after the first carry DRY_RUN_BATCH_GUID, since there's no server to hand out a real one
+func (c *Client) DryRunBatch(ctx context.Context, url string, batch *TagBatchPart, sink DryRunSink) (*SendBatchResult, error) {
+	batchBuilder, ret, err := c.newBatchBuilderFor(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	s := c.newBatchSender(url, ret)
+	s.dryRun = sink
+	s.concurrency = 1 // sinks don't need to handle concurrent writes
+	if err := s.run(ctx, batchBuilder); err != nil {
+		return ret, err
+	}
+
+	manifest := &DryRunManifest{
+		URL:          url,
+		ReplaceAll:   batch.ReplaceAll,
+		TTLMinutes:   batch.TTLMinutes,
+		UpsertsTotal: ret.UpsertsTotal,
+		DeletesTotal: ret.DeletesTotal,
+		Parts:        s.dryRunParts,
+	}
+	if err := sink.WriteManifest(manifest); err != nil {
+		return ret, fmt.Errorf("Error writing dry run manifest: %w", err)
+	}
+	return ret, nil
+}
+
+// writeDryRunPart hands a part to the dry run sink, in place of sending it
+func (s *batchSender) writeDryRunPart(part *outgoingPart) error {
+	dryRunPart := DryRunPart{
+		Index:       part.index,
+		UpsertCount: part.upsertCount,
+		DeleteCount: part.deleteCount,
+		RawBytes:    len(part.raw),
+		BodyBytes:   len(part.body),
+		Encoding:    s.encoder.ContentEncoding(),
+		Complete:    part.complete,
+	}
+	if err := s.dryRun.WritePart(&dryRunPart, part.raw, part.body); err != nil {
+		return err
+	}
+	s.dryRunParts = append(s.dryRunParts, dryRunPart)
+	return nil
+}
+
+// DirDryRunSink writes each part to its own file in a directory, as part-0001.json, or part-0001.json.gz
+// if compression is enabled, along with manifest.json. Use a separate directory for each batch.
+type DirDryRunSink struct {
+	dir string
+}
+
+// NewDirDryRunSink builds a DirDryRunSink, creating the directory if needed
+func NewDirDryRunSink(dir string) (*DirDryRunSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating dry run directory: %s", err)
+	}
+	return &DirDryRunSink{dir: dir}, nil
+}
+
+// WritePart writes the request body to the part's file
+func (s *DirDryRunSink) WritePart(part *DryRunPart, raw []byte, body []byte) error {
+	part.FileName = fmt.Sprintf("part-%04d.json", part.Index+1)
+	switch part.Encoding {
+	case "":
+	case "gzip":
+		part.FileName += ".gz"
+	case "zstd":
+		part.FileName += ".zst"
+	default:
+		part.FileName += "." + part.Encoding
+	}
+	return ioutil.WriteFile(filepath.Join(s.dir, part.FileName), body, 0644)
+}
+
+// WriteManifest writes manifest.json
+func (s *DirDryRunSink) WriteManifest(manifest *DryRunManifest) error {
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.dir, "manifest.json"), append(manifestBytes, '\n'), 0644)
+}
+
+// WriterDryRunSink writes each part's JSON to a writer on its own line, followed by the manifest on the last line
+type WriterDryRunSink struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewWriterDryRunSink builds a WriterDryRunSink
+func NewWriterDryRunSink(w io.Writer) *WriterDryRunSink {
+	return &WriterDryRunSink{w: w}
+}
+
+// WritePart writes the part's JSON, uncompressed, followed by a newline
+func (s *WriterDryRunSink) WritePart(part *DryRunPart, raw []byte, body []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := s.w.Write(raw); err != nil {
+		return err
+	}
+	_, err := io.WriteString(s.w, "\n")
+	return err
+}
+
+// WriteManifest writes the manifest's JSON, followed by a newline
+func (s *WriterDryRunSink) WriteManifest(manifest *DryRunManifest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(manifestBytes); err != nil {
+		return err
+	}
+	_, err = io.WriteString(s.w, "\n")
+	return err
+}
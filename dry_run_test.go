@@ -0,0 +1,102 @@
+package hippo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunBatch_Dir(t *testing.T) {
+	a := require.New(t)
+
+	// nothing should be sent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Fail("dry run sent a request")
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "hippo-dry-run")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	sink, err := NewDirDryRunSink(filepath.Join(dir, "batch"))
+	a.NoError(err)
+
+	client := NewHippo("", "", "")
+	client.OutgoingRequestSize = 500
+	result, err := client.DryRunBatch(context.Background(), server.URL, buildTestBatch(10, true), sink)
+	a.NoError(err)
+	a.Equal(DRY_RUN_BATCH_GUID, result.BatchGUID)
+	a.Equal(10, result.UpsertsSent)
+	a.True(result.PartsSent > 1)
+
+	manifestBytes, err := ioutil.ReadFile(filepath.Join(dir, "batch", "manifest.json"))
+	a.NoError(err)
+	manifest := DryRunManifest{}
+	a.NoError(json.Unmarshal(manifestBytes, &manifest))
+	a.Equal(server.URL, manifest.URL)
+	a.True(manifest.ReplaceAll)
+	a.Equal(10, manifest.UpsertsTotal)
+	a.Equal(result.PartsSent, len(manifest.Parts))
+
+	upserts := 0
+	for i, part := range manifest.Parts {
+		a.Equal(i, part.Index)
+		a.Equal("gzip", part.Encoding)
+		a.Equal(i == len(manifest.Parts)-1, part.Complete)
+
+		body, err := ioutil.ReadFile(filepath.Join(dir, "batch", part.FileName))
+		a.NoError(err)
+		a.Equal(part.BodyBytes, len(body))
+		raw := gzipUncompress(a, body)
+		a.Equal(part.RawBytes, len(raw))
+
+		batchPart := TagBatchPart{}
+		a.NoError(json.Unmarshal(raw, &batchPart))
+		a.Equal(part.UpsertCount, len(batchPart.Upserts))
+		a.Equal(part.Complete, batchPart.IsComplete)
+		if i > 0 {
+			a.Equal(DRY_RUN_BATCH_GUID, batchPart.BatchGUID)
+		}
+		upserts += part.UpsertCount
+	}
+	a.Equal(10, upserts)
+	a.Equal("part-0001.json.gz", manifest.Parts[0].FileName)
+}
+
+func TestDryRunBatch_Writer(t *testing.T) {
+	a := require.New(t)
+
+	var buf bytes.Buffer
+	client, err := NewClient(WithCompression(false), WithMaxPartSize(500))
+	a.NoError(err)
+	result, err := client.DryRunBatch(context.Background(), "http://localhost/unused", buildTestBatch(10, false), NewWriterDryRunSink(&buf))
+	a.NoError(err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	a.Equal(result.PartsSent+1, len(lines))
+
+	for _, line := range lines[:len(lines)-1] {
+		batchPart := TagBatchPart{}
+		a.NoError(json.Unmarshal([]byte(line), &batchPart))
+		a.NotEmpty(batchPart.Upserts)
+	}
+
+	manifest := DryRunManifest{}
+	a.NoError(json.Unmarshal([]byte(lines[len(lines)-1]), &manifest))
+	a.Equal(result.PartsSent, len(manifest.Parts))
+	for _, part := range manifest.Parts {
+		a.Empty(part.Encoding)
+		a.Equal(part.RawBytes, part.BodyBytes)
+		a.Empty(part.FileName)
+	}
+}
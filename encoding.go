@@ -0,0 +1,199 @@
+package hippo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// BodyEncoder compresses request bodies
+type BodyEncoder interface {
+	// ContentEncoding returns the Content-Encoding header for encoded bodies - empty if they aren't compressed
+	ContentEncoding() string
+
+	// Encode writes the encoded form of src to dst. It may be called from multiple goroutines at once.
+	Encode(dst io.Writer, src []byte) error
+}
+
+// IdentityEncoder sends request bodies uncompressed
+var IdentityEncoder BodyEncoder = identityEncoder{}
+
+type identityEncoder struct{}
+
+func (identityEncoder) ContentEncoding() string {
+	return ""
+}
+
+func (identityEncoder) Encode(dst io.Writer, src []byte) error {
+	_, err := dst.Write(src)
+	return err
+}
+
+// GzipEncoder gzips request bodies at a given compression level, reusing gzip.Writers between requests
+type GzipEncoder struct {
+	level int
+	pool  sync.Pool
+}
+
+// NewGzipEncoder builds a GzipEncoder for one of the compress/gzip levels, such as gzip.BestSpeed
+func NewGzipEncoder(level int) (*GzipEncoder, error) {
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, fmt.Errorf("Invalid gzip compression level: %s", err)
+	}
+	return &GzipEncoder{level: level}, nil
+}
+
+// ContentEncoding returns "gzip"
+func (e *GzipEncoder) ContentEncoding() string {
+	return "gzip"
+}
+
+// Encode gzips src into dst
+func (e *GzipEncoder) Encode(dst io.Writer, src []byte) error {
+	zw, ok := e.pool.Get().(*gzip.Writer)
+	if ok {
+		zw.Reset(dst)
+	} else {
+		// can't fail - the level was checked when the encoder was built
+		zw, _ = gzip.NewWriterLevel(dst, e.level)
+	}
+	defer e.pool.Put(zw)
+
+	if _, err := zw.Write(src); err != nil {
+		return fmt.Errorf("Error gzipping request body: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("Error closing gzip writer: %s", err)
+	}
+	return nil
+}
+
+// funcEncoder adapts a function to a BodyEncoder
+type funcEncoder struct {
+	contentEncoding string
+	encode          func(dst io.Writer, src []byte) error
+}
+
+// NewBodyEncoder builds a BodyEncoder from a Content-Encoding and an encoding function, to plug in other
+// compression, such as zstd, for endpoints that accept it. For example, with github.com/klauspost/compress/zstd:
+//
+//	zenc, _ := zstd.NewWriter(nil)
+//	encoder := hippo.NewBodyEncoder("zstd", func(dst io.Writer, src []byte) error {
+//		_, err := dst.Write(zenc.EncodeAll(src, nil))
+//		return err
+//	})
+func NewBodyEncoder(contentEncoding string, encode func(dst io.Writer, src []byte) error) BodyEncoder {
+	return funcEncoder{contentEncoding: contentEncoding, encode: encode}
+}
+
+func (e funcEncoder) ContentEncoding() string {
+	return e.contentEncoding
+}
+
+func (e funcEncoder) Encode(dst io.Writer, src []byte) error {
+	return e.encode(dst, src)
+}
+
+// defaultGzipEncoder is used unless the Client's been given another encoder
+var defaultGzipEncoder = &GzipEncoder{level: gzip.DefaultCompression}
+
+// bufferPool holds buffers for encoded request bodies
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// encodeBody encodes src into a pooled buffer - give it back with releaseBuffer once the request is done
+func encodeBody(encoder BodyEncoder, src []byte) (*bytes.Buffer, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := encoder.Encode(buf, src); err != nil {
+		releaseBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf != nil {
+		bufferPool.Put(buf)
+	}
+}
+
+// pooledBody is an encoded request body in a pooled buffer, shared by every request that sends it, such as the
+// attempts at a batch part. The buffer only goes back to the pool once its owner has released it, and every
+// request has closed its copy of the body, since net/http may still be reading a body after RoundTrip returns.
+// - a request that's never sent never closes its body, which just leaves the buffer to the garbage collector
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs int32 // the owner's, and one for each open reader
+}
+
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	return &pooledBody{buf: buf, refs: 1}
+}
+
+// Bytes returns the encoded body - only valid until it's released
+func (b *pooledBody) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// attach makes a copy of the body the request's body, and has GetBody return new copies
+func (b *pooledBody) attach(req *http.Request) {
+	req.Body = b.newReader()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return b.newReader(), nil
+	}
+	req.ContentLength = int64(b.buf.Len())
+}
+
+// release gives up the owner's reference to the buffer
+func (b *pooledBody) release() {
+	if b != nil {
+		b.unref()
+	}
+}
+
+func (b *pooledBody) newReader() io.ReadCloser {
+	atomic.AddInt32(&b.refs, 1)
+	return &pooledBodyReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+func (b *pooledBody) unref() {
+	if atomic.AddInt32(&b.refs, -1) == 0 {
+		releaseBuffer(b.buf)
+	}
+}
+
+// pooledBodyReader is a request's copy of a pooledBody
+type pooledBodyReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+// Close gives up the reader's reference to the buffer - net/http closes a request's body once it's done with it
+func (r *pooledBodyReader) Close() error {
+	r.once.Do(r.body.unref)
+	return nil
+}
+
+// bodyEncoder returns the encoder for request bodies
+func (c *Client) bodyEncoder() BodyEncoder {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.disableCompression {
+		return IdentityEncoder
+	}
+	if c.encoder != nil {
+		return c.encoder
+	}
+	return defaultGzipEncoder
+}
@@ -0,0 +1,139 @@
+package hippo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipEncoder(t *testing.T) {
+	a := require.New(t)
+
+	encoder, err := NewGzipEncoder(gzip.BestSpeed)
+	a.NoError(err)
+	a.Equal("gzip", encoder.ContentEncoding())
+
+	// encode several times, so pooled writers get reused
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		a.NoError(encoder.Encode(&buf, []byte(`{"upserts":[]}`)))
+		a.Equal(`{"upserts":[]}`, string(gzipUncompress(a, buf.Bytes())))
+	}
+
+	_, err = NewGzipEncoder(42)
+	a.Error(err)
+	_, err = NewClient(WithCompressionLevel(42))
+	a.Error(err)
+}
+
+func TestClient_BodyEncoder(t *testing.T) {
+	a := require.New(t)
+
+	// a stand-in for zstd, which the server can decode
+	encoder := NewBodyEncoder("base64", func(dst io.Writer, src []byte) error {
+		w := base64.NewEncoder(base64.StdEncoding, dst)
+		if _, err := w.Write(src); err != nil {
+			return err
+		}
+		return w.Close()
+	})
+
+	parts := make([]TagBatchPart, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("base64", r.Header.Get("Content-Encoding"))
+		body, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, r.Body))
+		a.NoError(err)
+		part := TagBatchPart{}
+		a.NoError(json.Unmarshal(body, &part))
+		parts = append(parts, part)
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBodyEncoder(encoder), WithMaxPartSize(300))
+	a.NoError(err)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(10, false))
+	a.NoError(err)
+	a.Equal(10, result.UpsertsSent)
+	a.Equal(result.PartsSent, len(parts))
+	a.True(len(parts) > 1)
+}
+
+func TestClient_NoCompression(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("", r.Header.Get("Content-Encoding"))
+		part := TagBatchPart{}
+		a.NoError(json.NewDecoder(r.Body).Decode(&part))
+		a.Equal(1, len(part.Upserts))
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	// disabling compression wins over the compression level
+	client, err := NewClient(WithCompressionLevel(gzip.BestCompression), WithCompression(false))
+	a.NoError(err)
+
+	_, err = client.SendBatch(context.Background(), server.URL, buildTestBatch(1, false))
+	a.NoError(err)
+}
+
+func BenchmarkSendBatch_Encoding(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(ioutil.Discard, r.Body)
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithMaxPartSize(10000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	batch := buildTestBatch(1000, true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.SendBatch(context.Background(), server.URL, batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// a pooled body is only released once its owner and every request body are done with it
+func TestPooledBody_Release(t *testing.T) {
+	a := require.New(t)
+
+	buf := bytes.NewBufferString("body")
+	body := newPooledBody(buf)
+
+	req := httptest.NewRequest("POST", "http://localhost", nil)
+	body.attach(req)
+	a.Equal(int64(4), req.ContentLength)
+	copied, err := req.GetBody()
+	a.NoError(err)
+	a.Equal(int32(3), body.refs)
+
+	// the owner's done, but requests may still be reading
+	body.release()
+	read, err := ioutil.ReadAll(req.Body)
+	a.NoError(err)
+	a.Equal("body", string(read))
+
+	a.NoError(req.Body.Close())
+	a.NoError(req.Body.Close()) // closing again doesn't release it twice
+	a.Equal(int32(1), body.refs)
+	a.NoError(copied.Close())
+	a.Equal(int32(0), body.refs)
+}
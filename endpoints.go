@@ -0,0 +1,158 @@
+package hippo
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Region is a Kentik region, which has its own API base URL
+type Region string
+
+const (
+	RegionUS Region = "us"
+	RegionEU Region = "eu"
+
+	MAX_DIMENSION_NAME_LEN = 20 // longest name allowed for a customer-created custom dimension
+)
+
+// base URLs of the Kentik API in each region
+var regionBaseURLs = map[Region]string{
+	RegionUS: "https://api.kentik.com",
+	RegionEU: "https://api.kentik.eu",
+}
+
+// compiled regular expression - for custom dimension names
+var _dimensionNameRegexp *regexp.Regexp
+
+// init function for this file - evaluated in init.go
+func initEndpoints() {
+	dimensionNameRegexp, err := regexp.Compile(`^(c|kt)_[a-z0-9_]+$`)
+	if err != nil {
+		panic(fmt.Sprintf("Cannot compile dimensionNameRegexp: %s", err))
+	}
+	_dimensionNameRegexp = dimensionNameRegexp
+}
+
+// RegionBaseURL returns the base URL of the Kentik API in the given region
+func RegionBaseURL(region Region) (string, error) {
+	baseURL, ok := regionBaseURLs[Region(strings.ToLower(string(region)))]
+	if !ok {
+		return "", fmt.Errorf("Unknown region '%s'", region)
+	}
+	return baseURL, nil
+}
+
+// Endpoints builds the URLs of the Kentik API endpoints used by this package, relative to a base URL
+type Endpoints struct {
+	baseURL string
+}
+
+// NewEndpoints builds Endpoints for the given base URL, such as https://api.kentik.com, or a custom deployment
+func NewEndpoints(baseURL string) (Endpoints, error) {
+	normalized, err := normalizeBaseURL(baseURL)
+	if err != nil {
+		return Endpoints{}, err
+	}
+	return Endpoints{baseURL: normalized}, nil
+}
+
+// NewRegionEndpoints builds Endpoints for the given region
+func NewRegionEndpoints(region Region) (Endpoints, error) {
+	baseURL, err := RegionBaseURL(region)
+	if err != nil {
+		return Endpoints{}, err
+	}
+	return Endpoints{baseURL: baseURL}, nil
+}
+
+// BaseURL returns the base URL the endpoints are built from
+func (e Endpoints) BaseURL() string {
+	return e.baseURL
+}
+
+// Populators returns the URL to send batches of populators for the custom dimension with the given name
+func (e Endpoints) Populators(dimensionName string) (string, error) {
+	if err := ValidateDimensionName(dimensionName); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/v5/batch/customdimensions/%s/populators", e.baseURL, dimensionName), nil
+}
+
+// Tags returns the URL to send batches of tags
+func (e Endpoints) Tags() string {
+	return fmt.Sprintf("%s/api/v5/batch/tags", e.baseURL)
+}
+
+// BatchStatus returns the URL to check the status of the batch with the given GUID
+func (e Endpoints) BatchStatus(guid string) string {
+	return fmt.Sprintf("%s/api/v5/batch/%s/status", e.baseURL, url.PathEscape(guid))
+}
+
+// CustomDimensions returns the URL to list custom dimensions
+func (e Endpoints) CustomDimensions() string {
+	return fmt.Sprintf("%s/api/internal/customdimensions", e.baseURL)
+}
+
+// CustomDimension returns the URL to create a custom dimension
+func (e Endpoints) CustomDimension() string {
+	return fmt.Sprintf("%s/api/internal/customdimension", e.baseURL)
+}
+
+// Dimension returns the URL of the custom dimension with the given ID
+func (e Endpoints) Dimension(id int) string {
+	return fmt.Sprintf("%s/api/internal/customdimension/%d", e.baseURL, id)
+}
+
+// ValidateDimensionName checks that a custom dimension name is one the API will accept
+// - customer dimensions start with "c_", internal ones with "kt_"
+// - only lower case letters, digits and underscores are allowed
+func ValidateDimensionName(name string) error {
+	if name == "" {
+		return newValidationError("Invalid dimension name: name cannot be empty")
+	}
+	if !_dimensionNameRegexp.MatchString(name) {
+		return newValidationError("Invalid dimension name '%s': must start with 'c_' or 'kt_', followed by lower case letters, digits or underscores", name)
+	}
+	if strings.HasPrefix(name, "c_") && len(name) > MAX_DIMENSION_NAME_LEN {
+		return newValidationError("Invalid dimension name '%s': cannot be longer than %d characters", name, MAX_DIMENSION_NAME_LEN)
+	}
+	return nil
+}
+
+// Endpoints returns the endpoints for the Client's base URL
+func (c *Client) Endpoints() Endpoints {
+	return Endpoints{baseURL: c.baseURL}
+}
+
+// SendPopulators sends a batch of populators for the custom dimension with the given name.
+// The name is checked before anything is sent.
+func (c *Client) SendPopulators(ctx context.Context, dimensionName string, batch *TagBatchPart) (*SendBatchResult, error) {
+	url, err := c.Endpoints().Populators(dimensionName)
+	if err != nil {
+		return nil, err
+	}
+	return c.SendBatch(ctx, url, batch)
+}
+
+// SendTags sends a batch of tags
+func (c *Client) SendTags(ctx context.Context, batch *TagBatchPart) (*SendBatchResult, error) {
+	return c.SendBatch(ctx, c.Endpoints().Tags(), batch)
+}
+
+// normalizeBaseURL checks a base URL, and trims its trailing slash
+func normalizeBaseURL(baseURL string) (string, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("Invalid base URL '%s': %s", baseURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("Invalid base URL '%s': scheme must be http or https", baseURL)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("Invalid base URL '%s': missing host", baseURL)
+	}
+	return strings.TrimSuffix(baseURL, "/"), nil
+}
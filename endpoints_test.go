@@ -0,0 +1,69 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoints(t *testing.T) {
+	a := require.New(t)
+
+	endpoints, err := NewRegionEndpoints(RegionEU)
+	a.NoError(err)
+	a.Equal("https://api.kentik.eu/api/v5/batch/tags", endpoints.Tags())
+
+	endpoints, err = NewEndpoints("https://kentik.example.com/")
+	a.NoError(err)
+	url, err := endpoints.Populators("c_customer")
+	a.NoError(err)
+	a.Equal("https://kentik.example.com/api/v5/batch/customdimensions/c_customer/populators", url)
+	a.Equal("https://kentik.example.com/api/internal/customdimensions", endpoints.CustomDimensions())
+
+	_, err = NewRegionEndpoints("mars")
+	a.Error(err)
+	_, err = NewEndpoints("kentik.example.com")
+	a.Error(err)
+}
+
+func TestValidateDimensionName(t *testing.T) {
+	a := require.New(t)
+
+	a.NoError(ValidateDimensionName("c_customer"))
+	a.NoError(ValidateDimensionName("kt_internal_thing"))
+
+	for _, name := range []string{"", "customer", "c_", "C_customer", "c_cust-omer", "c_this_name_is_too_long"} {
+		err := ValidateDimensionName(name)
+		a.Error(err, name)
+		a.True(errors.Is(err, ErrValidation), name)
+	}
+}
+
+func TestClient_SendPopulators(t *testing.T) {
+	a := require.New(t)
+
+	paths := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+
+	_, err = client.SendPopulators(context.Background(), "c_customer", buildTestBatch(1, false))
+	a.NoError(err)
+	_, err = client.SendTags(context.Background(), buildTestBatch(1, false))
+	a.NoError(err)
+	a.Equal([]string{"/api/v5/batch/customdimensions/c_customer/populators", "/api/v5/batch/tags"}, paths)
+
+	// a bad name fails before anything is sent
+	_, err = client.SendPopulators(context.Background(), "customer", buildTestBatch(1, false))
+	a.True(errors.Is(err, ErrValidation))
+	a.Equal(2, len(paths))
+}
@@ -0,0 +1,151 @@
+package hippo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error classes - check for them with errors.Is
+var (
+	// ErrRetryable means the request failed in a way that may succeed if re-sent
+	ErrRetryable = errors.New("retryable error")
+
+	// ErrAuth means the credentials were missing, wrong, or not allowed to make the request
+	ErrAuth = errors.New("authentication error")
+
+	// ErrThrottled means the server rejected the request because we're sending too fast
+	ErrThrottled = errors.New("throttled")
+
+	// ErrValidation means the request itself was rejected - with an error status, or an error in a successful
+	// response - and will fail again if re-sent as-is
+	ErrValidation = errors.New("validation error")
+
+	// ErrNotFound means the thing asked for doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrCircuitOpen means the request wasn't sent, because the circuit breaker for every API base URL is open
+	ErrCircuitOpen = errors.New("circuit breaker open")
+
+	// ErrMissingBatchGUID means the server accepted the first part of a batch, but didn't return its GUID
+	ErrMissingBatchGUID = errors.New("API response did not include a batch GUID")
+
+	// ErrBatchGUIDMismatch means the server answered a batch part with a different batch's GUID
+	ErrBatchGUIDMismatch = errors.New("API response GUID did not match the batch GUID")
+)
+
+// APIError is an error returned by the Kentik API, either as an HTTP error status,
+// or as an error reported in an otherwise successful response.
+type APIError struct {
+	StatusCode  int           // HTTP status code
+	Message     string        // message from the server's response, if it had one
+	ServerError string        // error from the server's response, if it had one
+	Body        []byte        // raw response body
+	RetryAfter  time.Duration // how long the server asked us to wait before retrying - zero if it didn't say
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode >= 300 {
+		return fmt.Sprintf("http error %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("server message: %s; server error: %s", e.Message, e.ServerError)
+}
+
+// Is reports whether the error belongs to one of the error classes
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRetryable:
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	case ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrValidation:
+		if e.StatusCode < 300 && e.ServerError != "" {
+			// the server accepted the request, but rejected what was in it
+			return true
+		}
+		switch e.StatusCode {
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+			return true
+		}
+	}
+	return false
+}
+
+// alreadyInUse returns whether the server rejected creating something because it already exists
+func (e *APIError) alreadyInUse() bool {
+	return strings.Contains(string(e.Body), "already in use")
+}
+
+// TransportError is returned when a request failed without an HTTP response - we couldn't connect, the connection
+// failed, or the response couldn't be read. It's always retryable, though a request that may have reached the
+// server isn't necessarily safe to re-send.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error belongs to one of the error classes
+func (e *TransportError) Is(target error) bool {
+	return target == ErrRetryable
+}
+
+// BatchSendError is returned when sending a batch fails partway through
+type BatchSendError struct {
+	Result *SendBatchResult // how far we got before the failure
+	Part   int              // index of the part that failed, starting at 0
+	Err    error            // underlying error
+
+	msg string
+}
+
+func (e *BatchSendError) Error() string {
+	return e.msg
+}
+
+func (e *BatchSendError) Unwrap() error {
+	return e.Err
+}
+
+// newBatchSendError builds a BatchSendError for the given part, with a message that may include the progress so far
+func newBatchSendError(result *SendBatchResult, part int, err error, format string, args ...interface{}) *BatchSendError {
+	return &BatchSendError{
+		Result: result,
+		Part:   part,
+		Err:    err,
+		msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// validationError is an error found locally, before anything is sent
+type validationError struct {
+	msg string
+}
+
+func newValidationError(format string, args ...interface{}) error {
+	return &validationError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
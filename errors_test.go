@@ -0,0 +1,153 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError_Classes(t *testing.T) {
+	a := require.New(t)
+
+	tests := []struct {
+		statusCode int
+		retryable  bool
+		auth       bool
+		throttled  bool
+		validation bool
+	}{
+		{200, false, false, false, false},
+		{400, false, false, false, true},
+		{401, false, true, false, false},
+		{403, false, true, false, false},
+		{404, false, false, false, false},
+		{413, false, false, false, true},
+		{429, true, false, true, false},
+		{500, true, false, false, false},
+		{503, true, false, false, false},
+	}
+	for _, test := range tests {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: test.statusCode})
+		a.Equal(test.retryable, errors.Is(err, ErrRetryable), "status %d", test.statusCode)
+		a.Equal(test.auth, errors.Is(err, ErrAuth), "status %d", test.statusCode)
+		a.Equal(test.throttled, errors.Is(err, ErrThrottled), "status %d", test.statusCode)
+		a.Equal(test.validation, errors.Is(err, ErrValidation), "status %d", test.statusCode)
+	}
+
+	// an error reported in a successful response is a rejection of the request
+	err := &APIError{StatusCode: 200, ServerError: "invalid populator"}
+	a.True(errors.Is(err, ErrValidation))
+	a.False(errors.Is(err, ErrRetryable))
+}
+
+func TestAPIError_Error(t *testing.T) {
+	a := require.New(t)
+
+	a.Equal("http error 500: oops", (&APIError{StatusCode: 500, Body: []byte("oops")}).Error())
+	a.Equal("server message: msg; server error: err", (&APIError{StatusCode: 200, Message: "msg", ServerError: "err"}).Error())
+}
+
+// Test that a failed batch can be inspected with errors.As and errors.Is
+func TestSendBatch_TypedError(t *testing.T) {
+	a := require.New(t)
+
+	// setup test server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(401)
+		_, _ = w.Write([]byte(`{"message":"bad token","error":"Unauthorized"}`))
+	}))
+	defer ts.Close()
+
+	sut := NewHippo("agent", "email", "token")
+	batch := TagBatchPart{
+		Upserts: []TagUpsert{
+			{
+				Value: "test1",
+				Criteria: []TagCriteria{
+					{
+						Direction:   "src",
+						IPAddresses: []string{"1.2.3.4"},
+					},
+				},
+			},
+		},
+	}
+
+	response, err := sut.SendBatch(context.Background(), ts.URL, &batch)
+	a.Error(err)
+	a.True(errors.Is(err, ErrAuth))
+	a.False(errors.Is(err, ErrRetryable))
+
+	var sendErr *BatchSendError
+	a.True(errors.As(err, &sendErr))
+	a.Equal(0, sendErr.Part)
+	a.Equal(response, sendErr.Result)
+	a.Equal(1, sendErr.Result.UpsertsTotal)
+
+	var apiErr *APIError
+	a.True(errors.As(err, &apiErr))
+	a.Equal(401, apiErr.StatusCode)
+	a.Equal("bad token", apiErr.Message)
+	a.Equal("Unauthorized", apiErr.ServerError)
+}
+
+// Test that an error reported inside a successful response is typed, too
+func TestSendBatch_TypedServerError(t *testing.T) {
+	a := require.New(t)
+
+	// setup test server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"error":"Internal error processing request"}`))
+	}))
+	defer ts.Close()
+
+	sut := NewHippo("agent", "email", "token")
+	batch := TagBatchPart{ReplaceAll: true}
+
+	_, err := sut.SendBatch(context.Background(), ts.URL, &batch)
+	a.Error(err)
+
+	var apiErr *APIError
+	a.True(errors.As(err, &apiErr))
+	a.Equal(200, apiErr.StatusCode)
+	a.Equal("Internal error processing request", apiErr.ServerError)
+	a.True(errors.Is(err, ErrValidation))
+}
+
+// Test that local validation failures are classified as validation errors
+func TestDeleteValues_ValidationError(t *testing.T) {
+	a := require.New(t)
+
+	sut := NewHippo("agent", "email", "token")
+	_, err := sut.DeleteValues(context.Background(), "http://127.0.0.1:1/kentik/server/url", "")
+	a.True(errors.Is(err, ErrValidation))
+}
+
+// Test that failing to reach the server at all is classified as retryable
+func TestSendBatch_TransportError(t *testing.T) {
+	a := require.New(t)
+
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	client, err := NewClient()
+	a.NoError(err)
+	_, err = client.SendBatch(context.Background(), ts.URL, buildTestBatch(1, false))
+	a.True(errors.Is(err, ErrRetryable))
+	a.False(errors.Is(err, ErrValidation))
+
+	var transportErr *TransportError
+	a.True(errors.As(err, &transportErr))
+	a.True(isConnectError(err))
+
+	_, err = client.SendBatch(context.Background(), "http://host.invalid", buildTestBatch(1, false))
+	a.True(errors.Is(err, ErrRetryable))
+}
@@ -2,12 +2,10 @@ package hippo
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"net/url"
 	"sort"
@@ -23,6 +21,8 @@ type SendBatchResult struct {
 	DeletesSent  int
 	DeletesTotal int
 	BatchGUID    string
+	Parts        []PartResult  // details of each part sent, in the order the server accepted them
+	Failures     []PartFailure // parts that failed - sending stops after the first, but parts already in flight may fail too
 }
 
 func (r *SendBatchResult) String() string {
@@ -42,8 +42,27 @@ type Client struct {
 	UsrToken            string
 	OutgoingRequestSize int
 
-	sender TagBatchPartSender // optional - to help track batch origin
-	lock   sync.RWMutex
+	baseURL            string
+	disableCompression bool
+	sender             TagBatchPartSender // optional - to help track batch origin
+	retryPolicy        RetryPolicy        // optional - zero value doesn't retry
+	auth               Authenticator      // optional - nil authenticates with UsrEmail and UsrToken
+	limiter            RateLimiter        // optional - nil doesn't limit
+	partConcurrency    int                // how many parts after the first may be in flight at once
+	partSizing         PartSizing         // how parts are sized against OutgoingRequestSize
+	encoder            BodyEncoder        // optional - nil gzips request bodies at the default level
+	metrics            MetricsRecorder    // optional - nil doesn't record metrics
+	log                Logger             // optional - nil doesn't log
+	checkpoints        CheckpointStore    // optional - nil doesn't checkpoint batches
+	backends           *backendSet        // optional - base URLs to fail over between, with their circuit breakers
+	shrinkGuard        *ShrinkGuard       // optional - nil doesn't check replace-all batches
+
+	middleware           []Middleware
+	requestInterceptors  []RequestInterceptor
+	responseInterceptors []ResponseInterceptor
+	chain                *http.Client // http, with its transport wrapped in the middleware
+
+	lock sync.RWMutex
 }
 
 const (
@@ -64,32 +83,13 @@ type CustomDimensionList struct {
 	Dimensions []*CustomDimension `json:"customDimensions"`
 }
 
+// NewHippo builds a new Client with the default options, limited to DEFAULT_REQUESTS_PER_SECOND, the pace
+// Clients have always sent at - see NewClient for more control
 func NewHippo(agent string, email string, token string) *Client {
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-
-	client := &http.Client{
-		Transport: transport,
-	}
-
-	return &Client{
-		http:                client,
-		transport:           transport,
-		UsrAgent:            agent,
-		UsrEmail:            email,
-		UsrToken:            token,
-		OutgoingRequestSize: DEFAULT_MAX_HIPPO_SIZE,
-	}
+	// these options can't fail
+	client, _ := NewClient(WithUserAgent(agent), WithCredentials(email, token),
+		WithRateLimiter(NewTokenBucketLimiter(DEFAULT_REQUESTS_PER_SECOND, 1, 0, 0)))
+	return client
 }
 
 // SetSenderInfo sets optional metadata about the service sending batches
@@ -104,54 +104,258 @@ func (c *Client) SetSenderInfo(serviceName string, serviceInstance string, hostN
 	}
 }
 
+// SetRetryPolicy sets how batch parts are retried after transient failures
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.retryPolicy = policy
+}
+
+// SetAuthenticator sets how requests are authenticated - nil authenticates with UsrEmail and UsrToken.
+// It's safe to call while requests are in flight; requests made after it returns use the new authenticator.
+func (c *Client) SetAuthenticator(auth Authenticator) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.auth = auth
+}
+
+// SetRateLimiter sets the limiter that paces every request made through Do - nil disables rate limiting.
+// Share one limiter between Clients to coordinate them.
+func (c *Client) SetRateLimiter(limiter RateLimiter) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.limiter = limiter
+}
+
+// SetPartConcurrency sets how many batch parts may be in flight at once, after the first part returns the
+// batch GUID. The part that completes the batch is always sent last, once all the others have been accepted.
+// Requests still go through the rate limiter, which needs to allow enough requests for this to help.
+func (c *Client) SetPartConcurrency(concurrency int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	c.partConcurrency = concurrency
+}
+
+// SetPartSizing sets how batch parts are sized against OutgoingRequestSize - see PartSizing
+func (c *Client) SetPartSizing(sizing PartSizing) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.partSizing = sizing
+}
+
+// SetMetricsRecorder sets where the Client records its metrics - nil doesn't record metrics
+func (c *Client) SetMetricsRecorder(metrics MetricsRecorder) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.metrics = metrics
+}
+
+// SetLogger sets where the Client logs its events - nil doesn't log. Credentials are redacted from every event.
+func (c *Client) SetLogger(log Logger) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.log = log
+}
+
+// SetProxy sets the proxy used for requests
+// - the transport is copied with the new proxy, so requests already in flight keep the old one
+// - has no effect when the Client was built with a custom http.Client or RoundTripper, since that transport is the caller's
 func (c *Client) SetProxy(url *url.URL) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.transport == nil {
+		return
+	}
+	old := c.transport
+	c.transport = old.Clone()
 	c.transport.Proxy = http.ProxyURL(url)
+	httpClient := *c.http
+	httpClient.Transport = c.transport
+	c.http = &httpClient
+	c.buildMiddlewareChainLocked()
+	old.CloseIdleConnections()
+}
+
+// BaseURL returns the base URL of the Kentik API that the client was built with
+func (c *Client) BaseURL() string {
+	return c.baseURL
 }
 
 // NewGzipAPIRequest creates a new request with headers added including authentication
 func (c *Client) NewGzipAPIRequest(method string, url string, gzippedData []byte) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, bytes.NewReader(gzippedData))
+	return c.newAPIRequest(method, url, gzippedData, defaultGzipEncoder.ContentEncoding())
+}
+
+// newAPIRequest creates a new request with headers added including authentication, with a body that's
+// encoded with the given Content-Encoding - empty if it isn't
+func (c *Client) newAPIRequest(method string, url string, body []byte, contentEncoding string) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return c.prepareAPIRequest(req, contentEncoding)
+}
+
+// newPooledAPIRequest works like newAPIRequest, with a pooled body that the request holds until it's closed
+func (c *Client) newPooledAPIRequest(method string, url string, body *pooledBody, contentEncoding string) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	body.attach(req)
+	return c.prepareAPIRequest(req, contentEncoding)
+}
+
+// prepareAPIRequest adds the headers every request needs, including authentication
+func (c *Client) prepareAPIRequest(req *http.Request, contentEncoding string) (*http.Request, error) {
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Content-Encoding", "gzip")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", c.UsrAgent)
-	req.Header.Set("X-CH-Auth-Email", c.UsrEmail)
-	req.Header.Set("X-CH-Auth-API-Token", c.UsrToken)
+
+	c.lock.RLock()
+	auth := c.auth
+	c.lock.RUnlock()
+	if auth == nil {
+		auth = EmailTokenAuth{Email: c.UsrEmail, Token: c.UsrToken}
+	}
+	if err := auth.Authenticate(req); err != nil {
+		return nil, err
+	}
 	return req, nil
 }
 
 func (c *Client) Do(ctx context.Context, req *http.Request) ([]byte, error) {
+	if err := c.checkCircuits(req); err != nil {
+		return nil, err
+	}
+	if err := c.waitForRateLimit(ctx, req); err != nil {
+		return nil, err
+	}
+	return c.roundTrip(ctx, req)
+}
+
+// waitForRateLimit waits until the rate limiter allows the request
+func (c *Client) waitForRateLimit(ctx context.Context, req *http.Request) error {
+	c.lock.RLock()
+	limiter := c.limiter
+	c.lock.RUnlock()
+
+	if limiter == nil {
+		return nil
+	}
+	bodySize := 0
+	if req.ContentLength > 0 {
+		bodySize = int(req.ContentLength)
+	}
+	started := time.Now()
+	err := limiter.Wait(ctx, bodySize)
+	c.metricsRecorder().RateLimitWait(time.Since(started))
+	return err
+}
+
+// roundTrip makes the request, returning the response body, or an *APIError for error statuses
+// - with circuit breakers, it goes to the first base URL that's up
+func (c *Client) roundTrip(ctx context.Context, req *http.Request) ([]byte, error) {
+	if c.backends != nil {
+		return c.roundTripFailover(ctx, req, c.backends)
+	}
+	return c.roundTripOnce(ctx, req)
+}
+
+// roundTripOnce makes the request, as-is
+func (c *Client) roundTripOnce(ctx context.Context, req *http.Request) ([]byte, error) {
+	c.lock.RLock()
+	httpClient := c.chain
+	requestInterceptors := c.requestInterceptors
+	responseInterceptors := c.responseInterceptors
+	c.lock.RUnlock()
+
 	req = req.WithContext(ctx)
-	resp, err := c.http.Do(req)
+	if err := c.interceptRequest(req, requestInterceptors); err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
-		return nil, err
+		return nil, &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
+	c.metricsRecorder().HTTPResponse(resp.StatusCode)
 	buf, err := ioutil.ReadAll(resp.Body)
-	if err != nil || (resp.StatusCode >= 300) {
-		if err == nil {
-			err = fmt.Errorf("http error %d: %s", resp.StatusCode, buf)
-		}
+	if err != nil {
+		return nil, &TransportError{Err: err}
+	}
+	if err := c.interceptResponse(req, resp, buf, responseInterceptors); err != nil {
 		return nil, err
 	}
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       buf,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+
+		// pick out the server's message, if the body is an API response
+		apiResponse := APIServerResponse{}
+		if json.Unmarshal(buf, &apiResponse) == nil {
+			apiErr.Message = apiResponse.Message
+			apiErr.ServerError = apiResponse.Error
+		}
+		return nil, apiErr
+	}
 	return buf, nil
 }
 
 // SendBatch sends a batch to the server, in multiple requests, if necessary.
-// - does not currently support Deletes
 // - may return non-nil SendBatchResult on error, it'll report how far we got
-// - error will contain SendBatchResult info
+// - error will contain SendBatchResult info, and is a *BatchSendError once sending has started
 func (c *Client) SendBatch(ctx context.Context, url string, batch *TagBatchPart) (*SendBatchResult, error) {
+	return c.SendBatchWithOptions(ctx, url, batch, SendBatchOptions{})
+}
+
+// SendBatchWithOptions works like SendBatch, with options for this batch
+func (c *Client) SendBatchWithOptions(ctx context.Context, url string, batch *TagBatchPart, opts SendBatchOptions) (*SendBatchResult, error) {
+	batchBuilder, ret, err := c.newBatchBuilderFor(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	s := c.newBatchSender(url, ret)
+	s.progress = opts.Progress
+	s.enableCheckpoints(opts.CheckpointID)
+	s.guardShrink(batch.ReplaceAll, opts.AllowShrink, func() int { return ret.UpsertsTotal })
+	if err := s.checkShrink(); err != nil {
+		// the count's known up front, so refuse it before anything's sent
+		return ret, err
+	}
+	err = s.run(ctx, batchBuilder)
+	return ret, err
+}
+
+// newBatchBuilderFor compacts the batch and loads it into a new BatchBuilder, returning the builder
+// and a result to track progress sending it
+func (c *Client) newBatchBuilderFor(batch *TagBatchPart) (*BatchBuilder, *SendBatchResult, error) {
 	c.lock.RLock()
 	sender := c.sender
+	sizing := c.partSizing
 	c.lock.RUnlock()
 
 	// compact the batch, grouping the same values together
@@ -163,84 +367,45 @@ func (c *Client) SendBatch(ctx context.Context, url string, batch *TagBatchPart)
 	for i := range batch.Upserts {
 		upsert := batch.Upserts[i]
 		if err := batchBuilder.AddUpsert(&upsert); err != nil {
-			return nil, fmt.Errorf("Error adding upsert: %s", err)
+			return nil, nil, fmt.Errorf("Error adding upsert: %w", err)
+		}
+	}
+	for i := range batch.Deletes {
+		del := batch.Deletes[i]
+		if err := batchBuilder.AddDelete(&del); err != nil {
+			return nil, nil, fmt.Errorf("Error adding delete: %w", err)
 		}
 	}
 
+	if encoder := c.bodyEncoder(); sizing != PartSizeUncompressed && encoder.ContentEncoding() != "" {
+		batchBuilder.estimateCompression(encoder)
+	}
+
 	ret := &SendBatchResult{
 		UpsertsTotal: len(batch.Upserts),
 		DeletesTotal: len(batch.Deletes),
 		BatchGUID:    "", // not known until we send the first part
 	}
-	for {
-		batchBuilder.SetBatchGUID(ret.BatchGUID)
-		requestBytes, upsertCount, err := batchBuilder.BuildBatchRequest()
-		if err != nil {
-			return ret, fmt.Errorf("Error building batch: %s", err)
-		}
-		if requestBytes == nil {
-			// sent the last batch
-			break
-		}
-
-		// gzip compress the batch
-		gzippedBytes, err := gzipCompress(requestBytes)
-		if err != nil {
-			return ret, fmt.Errorf("Error gzipping JSON request: %s", err)
-		}
-
-		req, err := c.NewGzipAPIRequest("POST", url, gzippedBytes)
-		if err != nil {
-			return ret, fmt.Errorf("Error building request to %s - [%s] - underlying error: %s", url, ret, err)
-		}
-		responseBytes, err := c.Do(ctx, req)
-		if err != nil {
-			return ret, fmt.Errorf("Error POSTing populators to %s (%d bytes) - [%s] - underlying error: %s", url, len(gzippedBytes), ret, err)
-		}
-
-		if ret.PartsSent == 0 {
-			// first response returns the batch GUID, which we need to include in subsequent batches
-			apiResponse := APIServerResponse{}
-			if err := json.Unmarshal(responseBytes, &apiResponse); err != nil {
-				return ret, fmt.Errorf("Error unmarshalling API batch response - [%s] - underlying error: %s", ret, err)
-			}
-			if apiResponse.Error != "" {
-				return ret, fmt.Errorf("API response contained an error - [%s] - server message: %s; server error: %s", ret, apiResponse.Message, apiResponse.Error)
-			}
-			if apiResponse.GUID == "" {
-				return ret, fmt.Errorf("API response did not include a GUID for subsequent batches - [%s] - server message: %s; server error: %s", ret, apiResponse.Message, apiResponse.Error)
-			}
-			ret.BatchGUID = apiResponse.GUID
-		}
-
-		// update response
-		ret.PartsSent++
-		ret.UpsertsSent += upsertCount
-		ret.DeletesSent += 0 // TODO: add support to this at some point
-
-		// slow down the HTTP batches a bit to avoid rate limiting
-		time.Sleep(time.Second)
-	}
-
-	return ret, nil
+	return batchBuilder, ret, nil
 }
 
-func gzipCompress(requestBody []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	zw := gzip.NewWriter(&buf)
-	if _, err := zw.Write(requestBody); err != nil {
-		return nil, fmt.Errorf("Error gzipping request body: %s", err)
-	}
-
-	if err := zw.Flush(); err != nil {
-		return nil, fmt.Errorf("Error flushing gzip writer: %s", err)
+// DeleteValues deletes the given values from the tag or custom dimension at the given URL.
+// - values are validated before anything is sent
+func (c *Client) DeleteValues(ctx context.Context, url string, values ...string) (*SendBatchResult, error) {
+	batch := NewTagBatch()
+	batch.IsComplete = true
+	for _, value := range values {
+		del := TagDelete{Value: value}
+		if valid, reason := del.Validate(); !valid {
+			return nil, newValidationError("Invalid delete: %s", reason)
+		}
+		batch.Deletes = append(batch.Deletes, del)
 	}
-
-	if err := zw.Close(); err != nil {
-		return nil, fmt.Errorf("Error closing gzip writer: %s", err)
+	if len(batch.Deletes) == 0 {
+		return nil, newValidationError("No values to delete")
 	}
 
-	return buf.Bytes(), nil
+	return c.SendBatch(ctx, url, &batch)
 }
 
 // Compact a request down to combine criteria with the same values, returning a new request.
@@ -258,6 +423,12 @@ func compactTagBatchPart(rFull TagBatchPart) *TagBatchPart {
 		rulesByLowerValue[valLower] = append(rulesByLowerValue[valLower], upsert.Criteria...)
 	}
 
+	// deletes are compacted the same way - only one delete per value
+	deletesByLowerValue := make(map[string]TagDelete)
+	for _, del := range rFull.Deletes {
+		deletesByLowerValue[strings.ToLower(del.Value)] = del
+	}
+
 	// re-build the upserts collection
 	// - start with a copied instance, which shares the underlying slices
 	// - then replace the Upserts slice
@@ -275,75 +446,31 @@ func compactTagBatchPart(rFull TagBatchPart) *TagBatchPart {
 		return ret.Upserts[i].Value < ret.Upserts[j].Value
 	})
 
+	if len(rFull.Deletes) > 0 {
+		ret.Deletes = make([]TagDelete, 0, len(deletesByLowerValue))
+		for _, del := range deletesByLowerValue {
+			ret.Deletes = append(ret.Deletes, del)
+		}
+		sort.SliceStable(ret.Deletes, func(i, j int) bool {
+			return ret.Deletes[i].Value < ret.Deletes[j].Value
+		})
+	}
+
 	return &ret
 }
 
-// Create any dimensions which are not present for the given company.
+// Create any dimensions which are not present for the given company, as string dimensions, returning how many
+// were created. required maps dimension names to display names.
+// - apiHost is the base URL of the API - the Client's base URL is used if it's empty
+// - see EnsureDimensionSpecs for other types, and a report of what happened to each dimension
 func (c *Client) EnsureDimensions(ctx context.Context, apiHost string, required map[string]string) (int, error) {
-	var currentSet CustomDimensionList
-	found := map[string]bool{}
-	done := 0
-
-	for col := range required {
-		found[col] = false
-	}
-
-	url := fmt.Sprintf("%s/api/internal/customdimensions", apiHost)
-	if req, err := c.NewGzipAPIRequest("GET", url, nil); err != nil {
-		return done, err
-	} else {
-		if res, err := c.Do(ctx, req); err != nil {
-			return done, err
-		} else {
-			if err := json.Unmarshal(res, &currentSet); err != nil {
-				return done, err
-			} else {
-				for _, dim := range currentSet.Dimensions {
-					if _, ok := found[dim.Name]; ok {
-						found[dim.Name] = true
-					}
-				}
-			}
-		}
+	specs := make([]DimensionSpec, 0, len(required))
+	for name, displayName := range required {
+		specs = append(specs, DimensionSpec{Name: name, DisplayName: displayName, Type: DimensionTypeString})
 	}
 
-	// Now, try to make any dimensions not found
-	for col, present := range found {
-		if !present {
-			cd := CustomDimension{
-				DisplayName: required[col],
-				Name:        col,
-				Type:        "string",
-				IsBulk:      true,
-				IsInternal:  strings.HasPrefix(col, "kt_"),
-			}
-			if b, err := json.Marshal(cd); err != nil {
-				return done, err
-			} else {
-				url := fmt.Sprintf("%s/api/internal/customdimension", apiHost)
-				gzippedBytes, err := gzipCompress(b)
-				if err != nil {
-					return done, fmt.Errorf("Error gzipping JSON request: %s", err)
-				}
-				if req, err := c.NewGzipAPIRequest("POST", url, gzippedBytes); err != nil {
-					return done, err
-				} else {
-					if _, err := c.Do(ctx, req); err != nil {
-						if !strings.Contains(err.Error(), "already in use") {
-							//fmt.Printf("Warn, ensuring dimension failed: %s\n", err)
-							return done, err
-						}
-						// The column already exists. This can happen for columns that don't have a 'c_' prefix, such as 'kt_' columns.
-						// We get into this situation because our API call to get existing columns doesn't return these columns,
-						// so we're here trying to create them.
-					} else {
-						done++
-					}
-				}
-			}
-		}
-	}
-	return done, nil
+	report, err := c.EnsureDimensionSpecs(ctx, apiHost, specs)
+	return len(report.Created), err
 }
 
 func TruncateStringForMaxTagLen(str string) string {
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -552,6 +553,215 @@ func TestMultiPartBatch_PartialSuccess(t *testing.T) {
 	a.Equal(expectedRequests[1], receivedRequests[1])
 }
 
+// Test that a failed part of a multiple-batch is retried with the same GUID and bytes
+func TestMultiPartBatch_RetrySuccess(t *testing.T) {
+	a := require.New(t)
+
+	cannedResponse := &APIServerResponse{
+		GUID:    "c8285742-f7a4-4870-933d-665b15c31eda",
+		Message: "success",
+		Error:   "",
+	}
+
+	lock := sync.Mutex{}
+	receivedRequests := make([]string, 0)
+
+	// setup test server
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/kentik/server/url", func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		jsonPayload := getJSON(a, r)
+		receivedRequests = append(receivedRequests, string(jsonPayload))
+
+		// second request is throttled, everything else succeeds
+		w.Header().Set("Content-Type", "application/json")
+		if len(receivedRequests) == 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(503)
+			_, _ = w.Write([]byte("slow down"))
+			return
+		}
+
+		responseBytes, err := json.Marshal(cannedResponse)
+		a.NoError(err)
+		w.WriteHeader(200)
+		_, _ = w.Write(responseBytes)
+	})
+
+	sut := NewHippo("agent", "email", "token")
+	sut.SetRetryPolicy(RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+	})
+
+	// force the client to use small batches
+	sut.OutgoingRequestSize = 150
+
+	batch := TagBatchPart{
+		ReplaceAll: true,
+		IsComplete: true,
+		Upserts: []TagUpsert{
+			{
+				Value: "test1",
+				Criteria: []TagCriteria{
+					{
+						Direction:   "src",
+						IPAddresses: []string{"1.2.3.4"},
+					},
+				},
+			},
+			{
+				Value: "test2",
+				Criteria: []TagCriteria{
+					{
+						Direction:   "src",
+						IPAddresses: []string{"2.2.3.4"},
+					},
+				},
+			},
+		},
+	}
+
+	url := fmt.Sprintf("%s/kentik/server/url", ts.URL)
+	response, err := sut.SendBatch(context.Background(), url, &batch)
+	a.NoError(err)
+	a.NotNil(response)
+
+	// verify the response
+	a.Equal(2, response.PartsSent)
+	a.Equal(2, response.UpsertsSent)
+	a.Equal(2, response.UpsertsTotal)
+	a.Equal(cannedResponse.GUID, response.BatchGUID)
+
+	// the throttled part was sent again, exactly as before
+	a.Equal(3, len(receivedRequests))
+	a.Equal(receivedRequests[1], receivedRequests[2])
+	a.Contains(receivedRequests[2], `"guid":"c8285742-f7a4-4870-933d-665b15c31eda"`)
+	a.Contains(receivedRequests[2], `"complete":true`)
+}
+
+// Test that a part which keeps failing gives up after the configured retries
+func TestSinglePartBatch_RetryExhausted(t *testing.T) {
+	a := require.New(t)
+
+	requestCount := 0
+
+	// setup test server
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/kentik/server/url", func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(500)
+		_, _ = w.Write([]byte("server error occurred"))
+	})
+
+	sut := NewHippo("agent", "email", "token")
+	sut.SetRetryPolicy(RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+	})
+
+	batch := TagBatchPart{
+		ReplaceAll: true,
+		Upserts: []TagUpsert{
+			{
+				Value: "test1",
+				Criteria: []TagCriteria{
+					{
+						Direction:   "src",
+						IPAddresses: []string{"1.2.3.4"},
+					},
+				},
+			},
+		},
+	}
+
+	url := fmt.Sprintf("%s/kentik/server/url", ts.URL)
+	response, err := sut.SendBatch(context.Background(), url, &batch)
+	a.Error(err)
+	a.Contains(err.Error(), "gave up after 3 attempts: http error 500: server error occurred")
+	a.NotNil(response)
+	a.Equal(0, response.PartsSent)
+	a.Equal(3, requestCount)
+}
+
+// Test deleting values through a fake server
+func TestDeleteValues_Success(t *testing.T) {
+	a := require.New(t)
+
+	serviceCalled := false
+
+	cannedResponse := &APIServerResponse{
+		GUID:    "c8285742-f7a4-4870-933d-665b15c31eda",
+		Message: "success",
+		Error:   "",
+	}
+
+	// setup test server
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/kentik/server/url", func(w http.ResponseWriter, r *http.Request) {
+		t.Helper()
+
+		serviceCalled = true
+
+		jsonPayload := getJSON(a, r)
+
+		// verify the expected request - values are compacted like upserts, taking the last case seen
+		expectedRequest := `{"guid":"","replace_all":false,"ttl_minutes":0,"upserts":[],"deletes":[{"value":"TEST1"},{"value":"test2"}],"complete":true}`
+		a.Equal(expectedRequest, string(jsonPayload))
+
+		// write the canned response
+		responseBytes, err := json.Marshal(cannedResponse)
+		a.NoError(err)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write(responseBytes)
+	})
+
+	sut := NewHippo("agent", "email", "token")
+
+	url := fmt.Sprintf("%s/kentik/server/url", ts.URL)
+	response, err := sut.DeleteValues(context.Background(), url, "test2", "test1", "TEST1")
+	a.NoError(err)
+	a.NotNil(response)
+
+	// make sure the fake web service was hit
+	a.True(serviceCalled)
+
+	// verify the response
+	a.Equal(1, response.PartsSent)
+	a.Equal(0, response.UpsertsSent)
+	a.Equal(0, response.UpsertsTotal)
+	a.Equal(2, response.DeletesSent)
+	a.Equal(2, response.DeletesTotal)
+	a.Equal(cannedResponse.GUID, response.BatchGUID)
+}
+
+// Test that invalid deletes are rejected before anything is sent
+func TestDeleteValues_Invalid(t *testing.T) {
+	a := require.New(t)
+
+	sut := NewHippo("agent", "email", "token")
+
+	response, err := sut.DeleteValues(context.Background(), "http://127.0.0.1:1/kentik/server/url", "test1", "")
+	a.Error(err)
+	a.Equal("Invalid delete: value cannot be empty", err.Error())
+	a.Nil(response)
+
+	response, err = sut.DeleteValues(context.Background(), "http://127.0.0.1:1/kentik/server/url")
+	a.Error(err)
+	a.Nil(response)
+}
+
 // TestCompactTagBatchPart tests that compactTagBatchPart compacts one upsert with two upserts with the same value
 // (but different cases) collapses them down into one with two rules
 func TestCompactTagBatchPart(t *testing.T) {
@@ -3,4 +3,5 @@ package hippo
 func init() {
 	initValidationRegexes()
 	initTagBatch()
+	initEndpoints()
 }
@@ -0,0 +1,121 @@
+package hippo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	REDACTED = "[REDACTED]"
+)
+
+// Logger receives structured log events - a message, followed by alternating keys and values.
+// *slog.Logger satisfies it, as do thin wrappers around most structured loggers.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// nopLogger is used when the Client has no Logger
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyvals ...interface{}) {}
+func (nopLogger) Info(msg string, keyvals ...interface{})  {}
+func (nopLogger) Warn(msg string, keyvals ...interface{})  {}
+func (nopLogger) Error(msg string, keyvals ...interface{}) {}
+
+// secretHolder is implemented by Authenticators that know the secrets they send, so they can be redacted from logs
+type secretHolder interface {
+	secrets() []string
+}
+
+// logger returns the Client's Logger, wrapped to redact its credentials - never nil
+func (c *Client) logger() Logger {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.log == nil {
+		return nopLogger{}
+	}
+
+	secrets := make([]string, 0, 2)
+	if c.UsrToken != "" {
+		secrets = append(secrets, c.UsrToken)
+	}
+	if holder, ok := c.auth.(secretHolder); ok {
+		secrets = append(secrets, holder.secrets()...)
+	}
+	return &redactingLogger{next: c.log, secrets: secrets}
+}
+
+// redactingLogger redacts secrets from log events, and the values of keys that look sensitive
+type redactingLogger struct {
+	next    Logger
+	secrets []string
+}
+
+func (l *redactingLogger) Debug(msg string, keyvals ...interface{}) {
+	l.next.Debug(l.redactString(msg), l.redact(keyvals)...)
+}
+
+func (l *redactingLogger) Info(msg string, keyvals ...interface{}) {
+	l.next.Info(l.redactString(msg), l.redact(keyvals)...)
+}
+
+func (l *redactingLogger) Warn(msg string, keyvals ...interface{}) {
+	l.next.Warn(l.redactString(msg), l.redact(keyvals)...)
+}
+
+func (l *redactingLogger) Error(msg string, keyvals ...interface{}) {
+	l.next.Error(l.redactString(msg), l.redact(keyvals)...)
+}
+
+func (l *redactingLogger) redact(keyvals []interface{}) []interface{} {
+	ret := make([]interface{}, len(keyvals))
+	for i, val := range keyvals {
+		if i%2 == 1 {
+			if key, ok := keyvals[i-1].(string); ok && isSensitiveKey(key) {
+				ret[i] = REDACTED
+				continue
+			}
+		}
+
+		switch v := val.(type) {
+		case string:
+			ret[i] = l.redactString(v)
+		case error:
+			// keep the error, unless it mentions a secret
+			if msg := v.Error(); l.redactString(msg) != msg {
+				ret[i] = l.redactString(msg)
+			} else {
+				ret[i] = v
+			}
+		case fmt.Stringer:
+			if str := v.String(); l.redactString(str) != str {
+				ret[i] = l.redactString(str)
+			} else {
+				ret[i] = v
+			}
+		default:
+			ret[i] = val
+		}
+	}
+	return ret
+}
+
+func (l *redactingLogger) redactString(str string) string {
+	for _, secret := range l.secrets {
+		if secret != "" {
+			str = strings.ReplaceAll(str, secret, REDACTED)
+		}
+	}
+	return str
+}
+
+// isSensitiveKey returns whether a log key's value should never be logged
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "token") || strings.Contains(key, "authorization") || strings.Contains(key, "password")
+}
@@ -0,0 +1,156 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// logEvent is a logged event, as recorded by testLogger
+type logEvent struct {
+	level   string
+	msg     string
+	keyvals []interface{}
+}
+
+// testLogger records log events
+type testLogger struct {
+	lock   sync.Mutex
+	events []logEvent
+}
+
+func (l *testLogger) Debug(msg string, keyvals ...interface{}) { l.record("debug", msg, keyvals) }
+func (l *testLogger) Info(msg string, keyvals ...interface{})  { l.record("info", msg, keyvals) }
+func (l *testLogger) Warn(msg string, keyvals ...interface{})  { l.record("warn", msg, keyvals) }
+func (l *testLogger) Error(msg string, keyvals ...interface{}) { l.record("error", msg, keyvals) }
+
+func (l *testLogger) record(level string, msg string, keyvals []interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, logEvent{level: level, msg: msg, keyvals: keyvals})
+}
+
+// messages returns the message of each event, prefixed with its level
+func (l *testLogger) messages() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	ret := make([]string, 0, len(l.events))
+	for _, event := range l.events {
+		ret = append(ret, event.level+": "+event.msg)
+	}
+	return ret
+}
+
+// String formats every event, for checking what was logged
+func (l *testLogger) String() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	sb := strings.Builder{}
+	for _, event := range l.events {
+		sb.WriteString(fmt.Sprintln(event.level, event.msg, event.keyvals))
+	}
+	return sb.String()
+}
+
+func TestClient_LogBatchEvents(t *testing.T) {
+	a := require.New(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	log := &testLogger{}
+	client, err := NewClient(WithCredentials("test@example.com", "secret-token"), WithMaxPartSize(300),
+		WithLogger(log), WithRetryPolicy(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}))
+	a.NoError(err)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(10, false))
+	a.NoError(err)
+
+	messages := log.messages()
+	a.Contains(messages, "debug: built batch part")
+	a.Contains(messages, "debug: sent batch part")
+	a.Contains(messages, "info: received batch GUID")
+	a.Contains(messages, "warn: throttled by server")
+	a.Contains(messages, "warn: retrying batch part")
+	a.NotContains(messages, "error: batch failed")
+
+	sent := 0
+	for _, message := range messages {
+		if message == "debug: sent batch part" {
+			sent++
+		}
+	}
+	a.Equal(result.PartsSent, sent)
+	a.NotContains(log.String(), "secret-token")
+}
+
+func TestClient_LogDimensionEvents(t *testing.T) {
+	a := require.New(t)
+
+	server := newDimensionServer(a, &CustomDimension{ID: 1, Name: "c_existing"})
+	defer server.Close()
+
+	log := &testLogger{}
+	client, err := NewClient(WithBaseURL(server.URL), WithLogger(log))
+	a.NoError(err)
+
+	_, err = client.EnsureDimensions(context.Background(), "", map[string]string{"c_existing": "Existing", "c_new": "New"})
+	a.NoError(err)
+	a.Equal([]string{"info: created custom dimension"}, log.messages())
+}
+
+func TestRedactingLogger(t *testing.T) {
+	a := require.New(t)
+
+	log := &testLogger{}
+	client, err := NewClient(WithCredentials("test@example.com", "secret-token"), WithLogger(log))
+	a.NoError(err)
+
+	plainErr := errors.New("plain")
+	client.logger().Warn("using secret-token",
+		"error", fmt.Errorf("bad token secret-token"),
+		"other", plainErr,
+		"api_token", "anything",
+		"Authorization", "Bearer xyz",
+		"count", 3)
+
+	a.Len(log.events, 1)
+	a.Equal("using "+REDACTED, log.events[0].msg)
+	a.Equal([]interface{}{
+		"error", "bad token " + REDACTED,
+		"other", plainErr,
+		"api_token", REDACTED,
+		"Authorization", REDACTED,
+		"count", 3,
+	}, log.events[0].keyvals)
+
+	// tokens from authenticators are redacted too
+	log.events = nil
+	client.SetAuthenticator(BearerTokenAuth{Token: "bearer-secret"})
+	client.logger().Info("sent bearer-secret")
+	a.Equal("sent "+REDACTED, log.events[0].msg)
+
+	// no logger logs nothing
+	client.SetLogger(nil)
+	client.logger().Error("dropped")
+	a.Len(log.events, 1)
+}
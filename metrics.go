@@ -0,0 +1,182 @@
+package hippo
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsRecorder records metrics about what a Client does. It's called from multiple goroutines at once.
+type MetricsRecorder interface {
+	// PartSent is called for each batch part the server accepts, with its JSON and request body sizes
+	PartSent(rawBytes int, bodyBytes int)
+
+	// HTTPResponse is called for every HTTP response, whatever its status
+	HTTPResponse(statusCode int)
+
+	// Retry is called each time a batch part is re-sent
+	Retry()
+
+	// RateLimitWait is called with how long each request waited for the rate limiter
+	RateLimitWait(wait time.Duration)
+
+	// BatchDone is called when a batch has been sent, or has failed, with how long it took
+	BatchDone(duration time.Duration, err error)
+
+	// DimensionsEnsured is called after ensuring dimensions, with how many were created, and how many already existed
+	DimensionsEnsured(created int, existing int)
+}
+
+// nopMetrics is used when the Client has no MetricsRecorder
+type nopMetrics struct{}
+
+func (nopMetrics) PartSent(rawBytes int, bodyBytes int)        {}
+func (nopMetrics) HTTPResponse(statusCode int)                 {}
+func (nopMetrics) Retry()                                      {}
+func (nopMetrics) RateLimitWait(wait time.Duration)            {}
+func (nopMetrics) BatchDone(duration time.Duration, err error) {}
+func (nopMetrics) DimensionsEnsured(created int, existing int) {}
+
+// metricsRecorder returns the Client's MetricsRecorder, which is never nil
+func (c *Client) metricsRecorder() MetricsRecorder {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.metrics == nil {
+		return nopMetrics{}
+	}
+	return c.metrics
+}
+
+// ExpvarMetrics is a MetricsRecorder that publishes its metrics with expvar, as a map of counters
+type ExpvarMetrics struct {
+	vars *expvar.Map
+}
+
+// expvarLock makes looking up and publishing an ExpvarMetrics' map atomic, since expvar panics if a name's
+// published twice
+var expvarLock sync.Mutex
+
+// NewExpvarMetrics builds an ExpvarMetrics published under the given name. Clients built with the same name
+// share the same counters.
+func NewExpvarMetrics(name string) (*ExpvarMetrics, error) {
+	expvarLock.Lock()
+	defer expvarLock.Unlock()
+
+	existing := expvar.Get(name)
+	if existing == nil {
+		return &ExpvarMetrics{vars: expvar.NewMap(name)}, nil
+	}
+	vars, ok := existing.(*expvar.Map)
+	if !ok {
+		return nil, fmt.Errorf("expvar '%s' is already published, and isn't a map", name)
+	}
+	return &ExpvarMetrics{vars: vars}, nil
+}
+
+func (m *ExpvarMetrics) PartSent(rawBytes int, bodyBytes int) {
+	m.vars.Add("parts_sent", 1)
+	m.vars.Add("raw_bytes", int64(rawBytes))
+	m.vars.Add("body_bytes", int64(bodyBytes))
+}
+
+func (m *ExpvarMetrics) HTTPResponse(statusCode int) {
+	m.vars.Add("http_status_"+strconv.Itoa(statusCode), 1)
+}
+
+func (m *ExpvarMetrics) Retry() {
+	m.vars.Add("retries", 1)
+}
+
+func (m *ExpvarMetrics) RateLimitWait(wait time.Duration) {
+	m.vars.Add("rate_limit_wait_ns", int64(wait))
+}
+
+func (m *ExpvarMetrics) BatchDone(duration time.Duration, err error) {
+	m.vars.Add("batches", 1)
+	m.vars.Add("batch_duration_ns", int64(duration))
+	if err != nil {
+		m.vars.Add("batch_errors", 1)
+	}
+}
+
+func (m *ExpvarMetrics) DimensionsEnsured(created int, existing int) {
+	m.vars.Add("dimensions_created", int64(created))
+	m.vars.Add("dimensions_existing", int64(existing))
+}
+
+// MetricsCounter is a counter - prometheus.Counter satisfies it
+type MetricsCounter interface {
+	Add(delta float64)
+}
+
+// MetricsObserver records observations, such as in a histogram - prometheus.Observer satisfies it
+type MetricsObserver interface {
+	Observe(value float64)
+}
+
+// MetricsAdapter is a MetricsRecorder that updates counters and observers, so it can be bridged to a metrics
+// library, like Prometheus. Nil fields are skipped. For example:
+//
+//	responses := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "hippo_http_responses_total"}, []string{"code"})
+//	recorder := &hippo.MetricsAdapter{
+//		PartsSent:     partsSentCounter,
+//		HTTPResponses: func(code string) hippo.MetricsCounter { return responses.WithLabelValues(code) },
+//		BatchSeconds:  batchDurationHistogram,
+//	}
+type MetricsAdapter struct {
+	PartsSent          MetricsCounter
+	RawBytes           MetricsCounter
+	BodyBytes          MetricsCounter
+	HTTPResponses      func(statusCode string) MetricsCounter // returns the counter for a status code
+	Retries            MetricsCounter
+	RateLimitSeconds   MetricsObserver // seconds each request waited for the rate limiter
+	BatchSeconds       MetricsObserver // seconds each batch took to send
+	BatchErrors        MetricsCounter
+	DimensionsCreated  MetricsCounter
+	DimensionsExisting MetricsCounter
+}
+
+func (m *MetricsAdapter) PartSent(rawBytes int, bodyBytes int) {
+	addCounter(m.PartsSent, 1)
+	addCounter(m.RawBytes, float64(rawBytes))
+	addCounter(m.BodyBytes, float64(bodyBytes))
+}
+
+func (m *MetricsAdapter) HTTPResponse(statusCode int) {
+	if m.HTTPResponses != nil {
+		addCounter(m.HTTPResponses(strconv.Itoa(statusCode)), 1)
+	}
+}
+
+func (m *MetricsAdapter) Retry() {
+	addCounter(m.Retries, 1)
+}
+
+func (m *MetricsAdapter) RateLimitWait(wait time.Duration) {
+	if m.RateLimitSeconds != nil {
+		m.RateLimitSeconds.Observe(wait.Seconds())
+	}
+}
+
+func (m *MetricsAdapter) BatchDone(duration time.Duration, err error) {
+	if m.BatchSeconds != nil {
+		m.BatchSeconds.Observe(duration.Seconds())
+	}
+	if err != nil {
+		addCounter(m.BatchErrors, 1)
+	}
+}
+
+func (m *MetricsAdapter) DimensionsEnsured(created int, existing int) {
+	addCounter(m.DimensionsCreated, float64(created))
+	addCounter(m.DimensionsExisting, float64(existing))
+}
+
+func addCounter(counter MetricsCounter, delta float64) {
+	if counter != nil {
+		counter.Add(delta)
+	}
+}
@@ -0,0 +1,132 @@
+package hippo
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCounter counts for MetricsAdapter tests
+type testCounter struct {
+	lock  sync.Mutex
+	value float64
+}
+
+func (c *testCounter) Add(delta float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.value += delta
+}
+
+func (c *testCounter) Observe(value float64) {
+	c.Add(value)
+}
+
+func (c *testCounter) get() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.value
+}
+
+func TestClient_ExpvarMetrics(t *testing.T) {
+	a := require.New(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	// expvars can't be unpublished, so each run needs its own name
+	name := fmt.Sprintf("hippo_test_metrics_%d", time.Now().UnixNano())
+	metrics, err := NewExpvarMetrics(name)
+	a.NoError(err)
+	client, err := NewClient(WithMaxPartSize(300), WithMetrics(metrics),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}))
+	a.NoError(err)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(10, false))
+	a.NoError(err)
+
+	vars := expvar.Get(name).(*expvar.Map)
+	a.Equal(int64(result.PartsSent), vars.Get("parts_sent").(*expvar.Int).Value())
+	a.Equal(int64(result.PartsSent), vars.Get("http_status_200").(*expvar.Int).Value())
+	a.Equal(int64(1), vars.Get("http_status_503").(*expvar.Int).Value())
+	a.Equal(int64(1), vars.Get("retries").(*expvar.Int).Value())
+	a.Equal(int64(1), vars.Get("batches").(*expvar.Int).Value())
+	a.Nil(vars.Get("batch_errors"))
+	a.True(vars.Get("raw_bytes").(*expvar.Int).Value() > vars.Get("body_bytes").(*expvar.Int).Value())
+
+	// the same name shares counters
+	again, err := NewExpvarMetrics(name)
+	a.NoError(err)
+	again.Retry()
+	a.Equal(int64(2), vars.Get("retries").(*expvar.Int).Value())
+}
+
+// Test that ExpvarMetrics with the same name can be built concurrently
+func TestNewExpvarMetrics_Concurrent(t *testing.T) {
+	a := require.New(t)
+
+	name := fmt.Sprintf("hippo_test_concurrent_%d", time.Now().UnixNano())
+	metrics := make([]*ExpvarMetrics, 10)
+	wg := sync.WaitGroup{}
+	for i := range metrics {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			metrics[i], _ = NewExpvarMetrics(name)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, m := range metrics {
+		a.NotNil(m)
+		m.Retry()
+	}
+	a.Equal(int64(len(metrics)), expvar.Get(name).(*expvar.Map).Get("retries").(*expvar.Int).Value())
+}
+
+func TestClient_MetricsAdapter(t *testing.T) {
+	a := require.New(t)
+
+	server := newDimensionServer(a, &CustomDimension{ID: 1, Name: "c_existing"})
+	defer server.Close()
+
+	responses := make(map[string]*testCounter)
+	adapter := &MetricsAdapter{
+		HTTPResponses: func(statusCode string) MetricsCounter {
+			if responses[statusCode] == nil {
+				responses[statusCode] = &testCounter{}
+			}
+			return responses[statusCode]
+		},
+		RateLimitSeconds:   &testCounter{},
+		DimensionsCreated:  &testCounter{},
+		DimensionsExisting: &testCounter{},
+	}
+	client, err := NewClient(WithBaseURL(server.URL), WithMetrics(adapter))
+	a.NoError(err)
+
+	_, err = client.EnsureDimensions(context.Background(), "", map[string]string{"c_existing": "Existing", "c_new": "New"})
+	a.NoError(err)
+	a.Equal(1.0, adapter.DimensionsCreated.(*testCounter).get())
+	a.Equal(1.0, adapter.DimensionsExisting.(*testCounter).get())
+	a.Equal(2.0, responses["200"].get())
+
+	// nil fields are skipped
+	adapter.PartSent(10, 5)
+	adapter.BatchDone(time.Second, nil)
+}
@@ -0,0 +1,134 @@
+package hippo
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Middleware wraps the RoundTripper that every request made through Client.Do goes through, for things like
+// tracing headers or fault injection. Middleware sees every attempt, including retries.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper, for writing Middleware
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// RequestInterceptor is called with each request just before it's sent, after the rate limiter lets it through.
+// It may change the request's headers. req.GetBody returns a copy of the body, which is encoded as the
+// request's Content-Encoding says. Returning an error fails the request without sending it.
+type RequestInterceptor func(req *http.Request) error
+
+// ResponseInterceptor is called with each response the server returns, including error statuses, along with
+// the response body, which has already been read. Returning an error fails the request.
+type ResponseInterceptor func(req *http.Request, resp *http.Response, body []byte) error
+
+// Use adds middleware around the Client's transport. The first middleware added is the outermost, so it
+// sees requests first and responses last.
+func (c *Client) Use(middleware ...Middleware) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.middleware = append(c.middleware, middleware...)
+	c.buildMiddlewareChainLocked()
+}
+
+// AddRequestInterceptor adds an interceptor that's called with every request - interceptors are called in the
+// order they're added
+func (c *Client) AddRequestInterceptor(interceptor RequestInterceptor) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.requestInterceptors = append(c.requestInterceptors, interceptor)
+}
+
+// AddResponseInterceptor adds an interceptor that's called with every response - interceptors are called in the
+// order they're added
+func (c *Client) AddResponseInterceptor(interceptor ResponseInterceptor) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.responseInterceptors = append(c.responseInterceptors, interceptor)
+}
+
+// WithMiddleware adds middleware around the Client's transport - see Client.Use
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.middleware = append(cfg.client.middleware, middleware...)
+		return nil
+	}
+}
+
+// WithRequestInterceptor adds an interceptor that's called with every request
+func WithRequestInterceptor(interceptor RequestInterceptor) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.requestInterceptors = append(cfg.client.requestInterceptors, interceptor)
+		return nil
+	}
+}
+
+// WithResponseInterceptor adds an interceptor that's called with every response
+func WithResponseInterceptor(interceptor ResponseInterceptor) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.responseInterceptors = append(cfg.client.responseInterceptors, interceptor)
+		return nil
+	}
+}
+
+// buildMiddlewareChainLocked rebuilds the http.Client that requests are made with, wrapping the transport
+// in the middleware. SetProxy calls it again after swapping the transport.
+func (c *Client) buildMiddlewareChainLocked() {
+	if len(c.middleware) == 0 {
+		c.chain = c.http
+		return
+	}
+
+	transport := c.http.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+	chain := *c.http
+	chain.Transport = transport
+	c.chain = &chain
+}
+
+// interceptorError is an error returned by an interceptor - it's the caller's, so it doesn't count against
+// a base URL's circuit breaker
+type interceptorError struct {
+	msg string
+	err error
+}
+
+func (e *interceptorError) Error() string {
+	return e.msg
+}
+
+func (e *interceptorError) Unwrap() error {
+	return e.err
+}
+
+// interceptRequest calls the request interceptors
+func (c *Client) interceptRequest(req *http.Request, interceptors []RequestInterceptor) error {
+	for _, interceptor := range interceptors {
+		if err := interceptor(req); err != nil {
+			return &interceptorError{msg: fmt.Sprintf("Error intercepting request: %s", err), err: err}
+		}
+	}
+	return nil
+}
+
+// interceptResponse calls the response interceptors
+func (c *Client) interceptResponse(req *http.Request, resp *http.Response, body []byte, interceptors []ResponseInterceptor) error {
+	for _, interceptor := range interceptors {
+		if err := interceptor(req, resp, body); err != nil {
+			return &interceptorError{msg: fmt.Sprintf("Error intercepting response: %s", err), err: err}
+		}
+	}
+	return nil
+}
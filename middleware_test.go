@@ -0,0 +1,125 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Middleware(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"guid":"abc","message":"` + r.Header.Get("X-Trace-Id") + `"}`))
+	}))
+	defer server.Close()
+
+	order := make([]string, 0)
+	lock := sync.Mutex{}
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		order = append(order, event)
+	}
+	tracing := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			record("tracing")
+			req.Header.Set("X-Trace-Id", "trace-1")
+			return next.RoundTrip(req)
+		})
+	}
+	inner := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			record("inner")
+			return next.RoundTrip(req)
+		})
+	}
+
+	client, err := NewClient(WithMiddleware(tracing))
+	a.NoError(err)
+	client.Use(inner)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(1, false))
+	a.NoError(err)
+	a.Equal("trace-1", result.Parts[0].Message)
+	a.Equal([]string{"tracing", "inner"}, order)
+}
+
+func TestClient_Interceptors(t *testing.T) {
+	a := require.New(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	requestBodies := make([][]byte, 0)
+	statuses := make([]int, 0)
+	client, err := NewClient(WithRetryPolicy(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}),
+		WithRequestInterceptor(func(req *http.Request) error {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			bodyBytes, err := ioutil.ReadAll(body)
+			requestBodies = append(requestBodies, bodyBytes)
+			return err
+		}),
+		WithResponseInterceptor(func(req *http.Request, resp *http.Response, body []byte) error {
+			statuses = append(statuses, resp.StatusCode)
+			return nil
+		}))
+	a.NoError(err)
+
+	_, err = client.SendBatch(context.Background(), server.URL, buildTestBatch(1, false))
+	a.NoError(err)
+
+	// retries are intercepted too
+	a.Equal([]int{http.StatusServiceUnavailable, http.StatusOK}, statuses)
+	a.Len(requestBodies, 2)
+	a.Equal(requestBodies[0], requestBodies[1])
+	uncompressed := gzipUncompress(a, requestBodies[0])
+	a.True(strings.Contains(string(uncompressed), `"upserts":[`))
+
+	// an interceptor error fails the request without sending it
+	injected := errors.New("injected fault")
+	client.AddRequestInterceptor(func(req *http.Request) error {
+		return injected
+	})
+	_, err = client.SendBatch(context.Background(), server.URL, buildTestBatch(1, false))
+	a.True(errors.Is(err, injected))
+	a.Equal(2, requests)
+}
+
+func TestClient_ResponseInterceptorError(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"customDimensions":[]}`))
+	}))
+	defer server.Close()
+
+	audit := errors.New("audit log unavailable")
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+	client.AddResponseInterceptor(func(req *http.Request, resp *http.Response, body []byte) error {
+		return audit
+	})
+
+	_, err = client.ListDimensions(context.Background())
+	a.True(errors.Is(err, audit))
+}
@@ -0,0 +1,354 @@
+package hippo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	DEFAULT_API_BASE_URL = "https://api.kentik.com"
+)
+
+// Option configures a Client built with NewClient
+type Option func(*clientConfig) error
+
+// clientConfig collects options that need to be combined before the Client's HTTP client can be built
+type clientConfig struct {
+	client       *Client
+	httpClient   *http.Client
+	roundTripper http.RoundTripper
+	tlsConfig    *tls.Config
+	timeout      time.Duration
+	failoverURLs []string
+	breaker      *CircuitBreakerConfig
+}
+
+// NewClient builds a new Client from the given options
+//   - requests aren't rate limited unless WithRateLimiter is given. Clients from NewHippo keep the pace Clients
+//     have always sent at, with NewTokenBucketLimiter(DEFAULT_REQUESTS_PER_SECOND, 1, 0, 0).
+func NewClient(opts ...Option) (*Client, error) {
+	cfg := clientConfig{
+		client: &Client{
+			baseURL:             DEFAULT_API_BASE_URL,
+			OutgoingRequestSize: DEFAULT_MAX_HIPPO_SIZE,
+		},
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.httpClient != nil && cfg.roundTripper != nil {
+		return nil, fmt.Errorf("Cannot use both a custom http.Client and a custom RoundTripper")
+	}
+
+	// start with our own client, or a copy of the caller's, so we can set options without changing theirs
+	httpClient := &http.Client{Transport: newDefaultTransport()}
+	ownTransport := true // only a transport we built or cloned can be changed by SetProxy
+	if cfg.httpClient != nil {
+		hc := *cfg.httpClient
+		httpClient = &hc
+		ownTransport = false
+	}
+	if cfg.roundTripper != nil {
+		httpClient.Transport = cfg.roundTripper
+		ownTransport = false
+	}
+
+	if cfg.tlsConfig != nil {
+		transport, ok := httpClient.Transport.(*http.Transport)
+		switch {
+		case httpClient.Transport == nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case !ok:
+			return nil, fmt.Errorf("Cannot apply TLS config to a custom RoundTripper - configure TLS on the RoundTripper instead")
+		case cfg.httpClient != nil || cfg.roundTripper != nil:
+			// the caller's transport - copy it before changing it
+			transport = transport.Clone()
+		}
+		transport.TLSClientConfig = cfg.tlsConfig
+		httpClient.Transport = transport
+		ownTransport = true
+	}
+
+	if cfg.timeout > 0 {
+		httpClient.Timeout = cfg.timeout
+	}
+
+	c := cfg.client
+	c.http = httpClient
+	if ownTransport {
+		c.transport, _ = httpClient.Transport.(*http.Transport)
+	}
+	c.buildMiddlewareChainLocked()
+	if cfg.breaker != nil {
+		c.backends = newBackendSet(append([]string{c.baseURL}, cfg.failoverURLs...), *cfg.breaker)
+	}
+	return c, nil
+}
+
+func newDefaultTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			DualStack: true,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// WithBaseURL sets the base URL of the Kentik API, such as https://api.kentik.com
+func WithBaseURL(baseURL string) Option {
+	return func(cfg *clientConfig) error {
+		normalized, err := normalizeBaseURL(baseURL)
+		if err != nil {
+			return err
+		}
+		cfg.client.baseURL = normalized
+		return nil
+	}
+}
+
+// WithRegion sets the base URL of the Kentik API to the one for the given region
+func WithRegion(region Region) Option {
+	return func(cfg *clientConfig) error {
+		baseURL, err := RegionBaseURL(region)
+		if err != nil {
+			return err
+		}
+		cfg.client.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(agent string) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.UsrAgent = agent
+		return nil
+	}
+}
+
+// WithCredentials sets the email and API token used to authenticate with the Kentik API
+func WithCredentials(email string, token string) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.UsrEmail = email
+		cfg.client.UsrToken = token
+		return nil
+	}
+}
+
+// WithAuthenticator sets how requests are authenticated, instead of the email and token from WithCredentials
+func WithAuthenticator(auth Authenticator) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.auth = auth
+		return nil
+	}
+}
+
+// WithHTTPClient uses the given http.Client to send requests - the http.Client itself is copied, not modified
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(cfg *clientConfig) error {
+		if httpClient == nil {
+			return fmt.Errorf("http.Client cannot be nil")
+		}
+		cfg.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithRoundTripper uses the given RoundTripper to send requests
+func WithRoundTripper(roundTripper http.RoundTripper) Option {
+	return func(cfg *clientConfig) error {
+		if roundTripper == nil {
+			return fmt.Errorf("RoundTripper cannot be nil")
+		}
+		cfg.roundTripper = roundTripper
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS config used to connect to the API
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(cfg *clientConfig) error {
+		if tlsConfig == nil {
+			return fmt.Errorf("TLS config cannot be nil")
+		}
+		cfg.tlsConfig = tlsConfig.Clone()
+		return nil
+	}
+}
+
+// WithCACertFile trusts the PEM-encoded CA certificates in the given file, in addition to the system's
+func WithCACertFile(path string) Option {
+	return func(cfg *clientConfig) error {
+		pemBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Error reading CA certificate file: %s", err)
+		}
+
+		tlsConfig := cfg.ensureTLSConfig()
+		if tlsConfig.RootCAs == nil {
+			tlsConfig.RootCAs, err = x509.SystemCertPool()
+			if err != nil || tlsConfig.RootCAs == nil {
+				tlsConfig.RootCAs = x509.NewCertPool()
+			}
+		}
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pemBytes) {
+			return fmt.Errorf("No certificates found in CA certificate file '%s'", path)
+		}
+		return nil
+	}
+}
+
+// WithClientCertFiles presents the given PEM-encoded certificate and key to the server, for mutual TLS
+func WithClientCertFiles(certFile string, keyFile string) Option {
+	return func(cfg *clientConfig) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("Error loading client certificate: %s", err)
+		}
+
+		tlsConfig := cfg.ensureTLSConfig()
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+		return nil
+	}
+}
+
+func (cfg *clientConfig) ensureTLSConfig() *tls.Config {
+	if cfg.tlsConfig == nil {
+		cfg.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return cfg.tlsConfig
+}
+
+// WithTimeout sets the overall timeout for each HTTP request, including reading the response
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if timeout < 0 {
+			return fmt.Errorf("Timeout cannot be negative")
+		}
+		cfg.timeout = timeout
+		return nil
+	}
+}
+
+// WithMaxPartSize sets the size that batches are split up at - see OutgoingRequestSize
+func WithMaxPartSize(size int) Option {
+	return func(cfg *clientConfig) error {
+		if size <= 0 {
+			return fmt.Errorf("Max part size must be positive")
+		}
+		cfg.client.OutgoingRequestSize = size
+		return nil
+	}
+}
+
+// WithCompression sets whether request bodies are compressed - they are gzipped by default.
+// Disabling compression overrides WithCompressionLevel and WithBodyEncoder.
+func WithCompression(enabled bool) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.disableCompression = !enabled
+		return nil
+	}
+}
+
+// WithSenderInfo sets optional metadata about the service sending batches
+func WithSenderInfo(serviceName string, serviceInstance string, hostName string) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.sender = TagBatchPartSender{
+			ServiceName:     serviceName,
+			ServiceInstance: serviceInstance,
+			HostName:        hostName,
+		}
+		return nil
+	}
+}
+
+// WithRetryPolicy sets how batch parts are retried after transient failures
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.retryPolicy = policy
+		return nil
+	}
+}
+
+// WithPartConcurrency sets how many batch parts may be in flight at once - see Client.SetPartConcurrency
+func WithPartConcurrency(concurrency int) Option {
+	return func(cfg *clientConfig) error {
+		if concurrency < 1 {
+			return fmt.Errorf("Part concurrency must be at least 1")
+		}
+		cfg.client.partConcurrency = concurrency
+		return nil
+	}
+}
+
+// WithPartSizing sets how batch parts are sized against the maximum part size - see PartSizing
+func WithPartSizing(sizing PartSizing) Option {
+	return func(cfg *clientConfig) error {
+		switch sizing {
+		case PartSizeUncompressed, PartSizeEstimatedCompressed, PartSizeCompressed:
+		default:
+			return fmt.Errorf("Unknown part sizing %d", sizing)
+		}
+		cfg.client.partSizing = sizing
+		return nil
+	}
+}
+
+// WithCompressionLevel gzips request bodies at the given compress/gzip level, such as gzip.BestSpeed
+func WithCompressionLevel(level int) Option {
+	return func(cfg *clientConfig) error {
+		encoder, err := NewGzipEncoder(level)
+		if err != nil {
+			return err
+		}
+		cfg.client.encoder = encoder
+		return nil
+	}
+}
+
+// WithBodyEncoder sets how request bodies are compressed - see NewBodyEncoder to plug in zstd.
+// Only use encodings the endpoints accept.
+func WithBodyEncoder(encoder BodyEncoder) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.encoder = encoder
+		return nil
+	}
+}
+
+// WithMetrics sets where the Client records its metrics - see ExpvarMetrics and MetricsAdapter
+func WithMetrics(metrics MetricsRecorder) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.metrics = metrics
+		return nil
+	}
+}
+
+// WithLogger sets where the Client logs its events - credentials are redacted from every event
+func WithLogger(log Logger) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.log = log
+		return nil
+	}
+}
+
+// WithRateLimiter sets the limiter that paces every request - nil, the default, disables rate limiting
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.limiter = limiter
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package hippo
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient_Defaults(t *testing.T) {
+	a := require.New(t)
+
+	sut, err := NewClient()
+	a.NoError(err)
+	a.Equal(DEFAULT_API_BASE_URL, sut.BaseURL())
+	a.Equal(DEFAULT_MAX_HIPPO_SIZE, sut.OutgoingRequestSize)
+	a.NotNil(sut.transport)
+	a.Equal(sut.transport, sut.http.Transport)
+	a.Zero(sut.http.Timeout)
+	a.Nil(sut.limiter)
+
+	// NewHippo keeps the pace existing callers have always had
+	hippo := NewHippo("agent", "email", "token")
+	a.NotNil(hippo.limiter)
+}
+
+func TestNewClient_Options(t *testing.T) {
+	a := require.New(t)
+
+	sut, err := NewClient(
+		WithBaseURL("https://api.kentik.eu/"),
+		WithUserAgent("agent"),
+		WithCredentials("email", "token"),
+		WithTimeout(15*time.Second),
+		WithMaxPartSize(1000),
+		WithCompression(false),
+		WithSenderInfo("service", "instance", "host"),
+		WithRetryPolicy(DefaultRetryPolicy()),
+		WithRateLimiter(NewTokenBucketLimiter(10, 1, 0, 0)),
+		WithTLSConfig(&tls.Config{ServerName: "kentik"}),
+	)
+	a.NoError(err)
+	a.Equal("https://api.kentik.eu", sut.BaseURL())
+	a.Equal("agent", sut.UsrAgent)
+	a.Equal("email", sut.UsrEmail)
+	a.Equal("token", sut.UsrToken)
+	a.Equal(15*time.Second, sut.http.Timeout)
+	a.Equal(1000, sut.OutgoingRequestSize)
+	a.True(sut.disableCompression)
+	a.Equal("service", sut.sender.ServiceName)
+	a.Equal(3, sut.retryPolicy.MaxRetries)
+	a.NotNil(sut.limiter)
+	a.Equal("kentik", sut.transport.TLSClientConfig.ServerName)
+}
+
+func TestNewClient_InvalidOptions(t *testing.T) {
+	a := require.New(t)
+
+	_, err := NewClient(WithBaseURL("api.kentik.com"))
+	a.Error(err)
+	_, err = NewClient(WithBaseURL("https://"))
+	a.Error(err)
+	_, err = NewClient(WithMaxPartSize(0))
+	a.Error(err)
+	_, err = NewClient(WithHTTPClient(&http.Client{}), WithRoundTripper(http.DefaultTransport))
+	a.Error(err)
+	_, err = NewClient(WithCACertFile("/does/not/exist"))
+	a.Error(err)
+
+	// TLS can't be applied to a RoundTripper we don't know about
+	_, err = NewClient(WithRoundTripper(RoundTripperFunc(http.DefaultTransport.RoundTrip)), WithTLSConfig(&tls.Config{}))
+	a.Error(err)
+}
+
+// make sure we don't modify the caller's http.Client or transport
+func TestNewClient_HTTPClientNotModified(t *testing.T) {
+	a := require.New(t)
+
+	transport := &http.Transport{}
+	httpClient := &http.Client{Transport: transport}
+	sut, err := NewClient(WithHTTPClient(httpClient), WithTimeout(time.Second), WithTLSConfig(&tls.Config{ServerName: "kentik"}))
+	a.NoError(err)
+
+	a.Zero(httpClient.Timeout)
+	if transport.TLSClientConfig != nil {
+		// cloning a transport sets up its HTTP/2 defaults, but our config shouldn't leak into it
+		a.Empty(transport.TLSClientConfig.ServerName)
+	}
+	a.Equal(time.Second, sut.http.Timeout)
+	a.Equal("kentik", sut.transport.TLSClientConfig.ServerName)
+}
+
+// SetProxy swaps in a copy of a transport we own, and leaves the caller's alone
+func TestClient_SetProxy(t *testing.T) {
+	a := require.New(t)
+	proxy, err := url.Parse("http://proxy.example.com:3128")
+	a.NoError(err)
+
+	sut, err := NewClient()
+	a.NoError(err)
+	before := sut.transport
+	sut.SetProxy(proxy)
+	a.NotSame(before, sut.transport)
+	a.Same(sut.transport, sut.http.Transport)
+	a.Same(sut.http, sut.chain)
+	req := httptest.NewRequest("GET", "https://api.kentik.com", nil)
+	proxyURL, err := sut.transport.Proxy(req)
+	a.NoError(err)
+	a.Equal(proxy, proxyURL)
+	proxyURL, _ = before.Proxy(req)
+	a.NotEqual(proxy, proxyURL)
+
+	transport := &http.Transport{}
+	sut, err = NewClient(WithRoundTripper(transport))
+	a.NoError(err)
+	a.Nil(sut.transport)
+	sut.SetProxy(proxy)
+	a.Nil(transport.Proxy)
+	a.Same(transport, sut.http.Transport)
+}
+
+// Test sending a batch uncompressed through a custom RoundTripper
+func TestNewClient_RoundTripperUncompressed(t *testing.T) {
+	a := require.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Empty(r.Header.Get("Content-Encoding"))
+		body, err := ioutil.ReadAll(r.Body)
+		a.NoError(err)
+		a.Equal(`{"guid":"","replace_all":true,"ttl_minutes":0,"upserts":[],"complete":true}`, string(body))
+
+		responseBytes, err := json.Marshal(&APIServerResponse{GUID: "c8285742-f7a4-4870-933d-665b15c31eda"})
+		a.NoError(err)
+		w.WriteHeader(200)
+		_, _ = w.Write(responseBytes)
+	}))
+	defer ts.Close()
+
+	roundTrips := 0
+	sut, err := NewClient(
+		WithCompression(false),
+		WithRoundTripper(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			roundTrips++
+			return http.DefaultTransport.RoundTrip(req)
+		})),
+	)
+	a.NoError(err)
+	a.Nil(sut.transport)
+
+	result, err := sut.SendBatch(context.Background(), ts.URL, &TagBatchPart{ReplaceAll: true})
+	a.NoError(err)
+	a.Equal(1, result.PartsSent)
+	a.Equal(1, roundTrips)
+}
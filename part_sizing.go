@@ -0,0 +1,124 @@
+package hippo
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PartSizing is how batch parts are sized against the Client's OutgoingRequestSize
+type PartSizing int
+
+const (
+	// PartSizeUncompressed compares OutgoingRequestSize with the part's JSON - the default
+	PartSizeUncompressed PartSizing = iota
+
+	// PartSizeEstimatedCompressed compares OutgoingRequestSize with an estimate of the part's compressed size,
+	// based on how well a sample of the batch compresses, and how well the parts sent so far compressed
+	PartSizeEstimatedCompressed
+
+	// PartSizeCompressed works like PartSizeEstimatedCompressed, but also checks every part's actual compressed
+	// size, splitting parts that turn out too big before they're sent
+	PartSizeCompressed
+)
+
+const (
+	MAX_COMPRESSION_RATIO = 20 // never pack more than this many times OutgoingRequestSize of JSON into a part
+
+	compressionSampleSize   = 256 * 1024 // how much of a batch is compressed to estimate its compression ratio
+	compressionSafetyMargin = 1.1        // estimates are scaled by this, since parts won't compress exactly as well
+)
+
+// compressedTargetSize returns how much JSON to pack into a part to hit the desired compressed size,
+// given the expected compressed/uncompressed ratio - zero ratio means sizing by uncompressed JSON
+func compressedTargetSize(desiredSize int, ratio float64) int {
+	if ratio <= 0 {
+		return desiredSize
+	}
+	ratio *= compressionSafetyMargin
+	if ratio < 1.0/MAX_COMPRESSION_RATIO {
+		ratio = 1.0 / MAX_COMPRESSION_RATIO
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return int(float64(desiredSize) / ratio)
+}
+
+// estimateCompressionRatio encodes a sample of serialized items, spread evenly through them, returning the
+// compressed/uncompressed ratio, or zero if there's nothing to sample
+func estimateCompressionRatio(encoder BodyEncoder, items ...[][]byte) float64 {
+	total := 0
+	count := 0
+	for _, list := range items {
+		count += len(list)
+		for _, item := range list {
+			total += len(item)
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	// take every nth item, so the sample is spread over the whole batch
+	step := 1
+	if total > compressionSampleSize {
+		step = total/compressionSampleSize + 1
+	}
+	var sample bytes.Buffer
+	i := 0
+	for _, list := range items {
+		for _, item := range list {
+			if i%step == 0 {
+				sample.Write(item)
+				sample.WriteByte(',')
+			}
+			i++
+		}
+	}
+
+	if sample.Len() == 0 {
+		return 0
+	}
+	compressed, err := encodeBody(encoder, sample.Bytes())
+	if err != nil {
+		return 0
+	}
+	defer releaseBuffer(compressed)
+	return float64(compressed.Len()) / float64(sample.Len())
+}
+
+// partHeader holds the fields that are the same in every part of a batch
+type partHeader struct {
+	replaceAll bool
+	ttlMinutes uint32
+	sender     TagBatchPartSender
+}
+
+// serializeBatchPart serializes a batch part from already serialized upserts and deletes, as BatchBuilder would
+func serializeBatchPart(batchGUID string, header partHeader, upserts [][]byte, deletes [][]byte, complete bool) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := writeBatchPartHeader(buf, batchGUID, header.replaceAll, header.ttlMinutes, header.sender); err != nil {
+		return nil, err
+	}
+	if _, err := buf.Write(bytes.Join(upserts, []byte(","))); err != nil {
+		return nil, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if _, err := buf.WriteString(`]`); err != nil {
+		return nil, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if len(deletes) > 0 {
+		if _, err := buf.WriteString(`,"deletes":[`); err != nil {
+			return nil, fmt.Errorf("Error writing string to buffer: %s", err)
+		}
+		if _, err := buf.Write(bytes.Join(deletes, []byte(","))); err != nil {
+			return nil, fmt.Errorf("Error writing string to buffer: %s", err)
+		}
+		if _, err := buf.WriteString(`]`); err != nil {
+			return nil, fmt.Errorf("Error writing string to buffer: %s", err)
+		}
+	}
+	if _, err := buf.WriteString(`,"complete":` + boolString(complete) + `}`); err != nil {
+		return nil, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,194 @@
+package hippo
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dry-run a batch with the given sizing, returning the manifest
+func dryRunWithSizing(a *require.Assertions, sizing PartSizing, batch *TagBatchPart) *DryRunManifest {
+	client, err := NewClient(WithMaxPartSize(2000), WithPartSizing(sizing))
+	a.NoError(err)
+
+	sink := &manifestSink{}
+	_, err = client.DryRunBatch(context.Background(), "http://localhost/unused", batch, sink)
+	a.NoError(err)
+	return sink.manifest
+}
+
+// manifestSink is a DryRunSink that keeps just the manifest
+type manifestSink struct {
+	manifest *DryRunManifest
+}
+
+func (s *manifestSink) WritePart(part *DryRunPart, raw []byte, body []byte) error {
+	return nil
+}
+
+func (s *manifestSink) WriteManifest(manifest *DryRunManifest) error {
+	s.manifest = manifest
+	return nil
+}
+
+func TestPartSizing_Compressed(t *testing.T) {
+	a := require.New(t)
+
+	uncompressed := dryRunWithSizing(a, PartSizeUncompressed, buildTestBatch(500, true))
+	estimated := dryRunWithSizing(a, PartSizeEstimatedCompressed, buildTestBatch(500, true))
+	compressed := dryRunWithSizing(a, PartSizeCompressed, buildTestBatch(500, true))
+
+	// repetitive upserts compress well, so sizing by compressed size needs far fewer parts
+	a.True(len(estimated.Parts)*3 < len(uncompressed.Parts), "%d vs %d", len(estimated.Parts), len(uncompressed.Parts))
+	a.True(len(compressed.Parts)*3 < len(uncompressed.Parts), "%d vs %d", len(compressed.Parts), len(uncompressed.Parts))
+
+	upserts := 0
+	for i, part := range compressed.Parts {
+		a.True(part.BodyBytes <= 2000, part.BodyBytes)
+		a.Equal(i == len(compressed.Parts)-1, part.Complete)
+		upserts += part.UpsertCount
+	}
+	a.Equal(500, upserts)
+}
+
+func TestSendBatch_SplitOnRequestEntityTooLarge(t *testing.T) {
+	a := require.New(t)
+
+	// the server only takes parts with up to 3 upserts
+	lock := sync.Mutex{}
+	accepted := make([]TagBatchPart, 0)
+	rejected := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		part := TagBatchPart{}
+		a.NoError(json.Unmarshal(getJSON(a, r), &part))
+
+		lock.Lock()
+		defer lock.Unlock()
+		if len(part.Upserts) > 3 {
+			rejected++
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		accepted = append(accepted, part)
+		_, _ = w.Write([]byte(`{"guid":"c8285742-f7a4-4870-933d-665b15c31eda"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithMaxPartSize(1000))
+	a.NoError(err)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(20, true))
+	a.NoError(err)
+	a.True(rejected > 0)
+	a.Equal(20, result.UpsertsSent)
+	a.Equal(len(accepted), result.PartsSent)
+
+	values := make(map[string]bool)
+	for i, part := range accepted {
+		if i == 0 {
+			a.Equal("", part.BatchGUID)
+		} else {
+			a.Equal("c8285742-f7a4-4870-933d-665b15c31eda", part.BatchGUID)
+		}
+		a.True(part.ReplaceAll)
+		a.Equal(i == len(accepted)-1, part.IsComplete)
+		for _, upsert := range part.Upserts {
+			values[upsert.Value] = true
+		}
+	}
+	a.Equal(20, len(values))
+}
+
+// Test that once the server rejects a part as too big, the rest of the batch is built smaller, rather than
+// every part being rejected and split
+func TestSendBatch_LowersPartSizeAfterRequestEntityTooLarge(t *testing.T) {
+	a := require.New(t)
+
+	// the server only takes parts with up to 700 bytes of JSON
+	lock := sync.Mutex{}
+	accepted := 0
+	rejected := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := getJSON(a, r)
+
+		lock.Lock()
+		defer lock.Unlock()
+		if len(body) > 700 {
+			rejected++
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		accepted++
+		_, _ = w.Write([]byte(`{"guid":"c8285742-f7a4-4870-933d-665b15c31eda"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithMaxPartSize(1000))
+	a.NoError(err)
+
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(100, true))
+	a.NoError(err)
+	a.Equal(1, rejected)
+	a.Equal(100, result.UpsertsSent)
+	a.Equal(accepted, result.PartsSent)
+}
+
+func TestSendBatch_SplitSingleUpsertFails(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	a.NoError(err)
+
+	// two upserts are split once, then the first single upsert fails
+	result, err := client.SendBatch(context.Background(), server.URL, buildTestBatch(2, false))
+	a.Error(err)
+	a.Equal(0, result.PartsSent)
+}
+
+// Test that parts split for compressing worse than expected each get their own index, and so their own file
+func TestDryRunBatch_SplitPartsHaveTheirOwnFiles(t *testing.T) {
+	a := require.New(t)
+
+	dir, err := ioutil.TempDir("", "hippo-dry-run")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	sink, err := NewDirDryRunSink(dir)
+	a.NoError(err)
+
+	// repetitive upserts, then random ones, which compress far worse than the parts before them
+	batch := buildTestBatch(200, true)
+	random := rand.New(rand.NewSource(1))
+	for i := range batch.Upserts[100:] {
+		noise := make([]byte, 24)
+		_, _ = random.Read(noise)
+		batch.Upserts[100+i].Value = hex.EncodeToString(noise)
+	}
+
+	client, err := NewClient(WithMaxPartSize(2000), WithPartSizing(PartSizeCompressed))
+	a.NoError(err)
+	result, err := client.DryRunBatch(context.Background(), "http://localhost/unused", batch, sink)
+	a.NoError(err)
+	a.Equal(200, result.UpsertsSent)
+
+	files, err := ioutil.ReadDir(dir)
+	a.NoError(err)
+	a.Equal(result.PartsSent+1, len(files)) // and the manifest
+	for i, part := range result.Parts {
+		a.Equal(i, part.Index)
+	}
+}
@@ -0,0 +1,287 @@
+package hippo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_POOL_IDLE_TIMEOUT = 10 * time.Minute
+)
+
+// CredentialsLookup looks up the credentials of a tenant, when a ClientPool builds the tenant's Client
+type CredentialsLookup interface {
+	TenantCredentials(ctx context.Context, tenantID string) (Credentials, error)
+}
+
+// CredentialsLookupFunc adapts a function to a CredentialsLookup
+type CredentialsLookupFunc func(ctx context.Context, tenantID string) (Credentials, error)
+
+// TenantCredentials calls the function
+func (f CredentialsLookupFunc) TenantCredentials(ctx context.Context, tenantID string) (Credentials, error) {
+	return f(ctx, tenantID)
+}
+
+// PoolConfig configures a ClientPool
+type PoolConfig struct {
+	Lookup    CredentialsLookup // required
+	Options   []Option          // applied to every Client, such as WithBaseURL or WithRetryPolicy
+	Transport *http.Transport   // optional - shared by every Client; nil uses a default transport
+
+	// Options can't include WithHTTPClient or WithRoundTripper, since the pool supplies the transport, and
+	// TLS options give each Client its own copy of it - configure TLS on Transport instead. They can't include
+	// WithCredentials or WithAuthenticator either, since each tenant has its own credentials.
+
+	TenantRequestsPerSecond float64 // each tenant's budget - zero uses DEFAULT_REQUESTS_PER_SECOND
+	TenantBurst             int
+	TotalRequestsPerSecond  float64 // budget shared by every tenant - zero doesn't limit
+	TotalBurst              int
+
+	IdleTimeout time.Duration // Clients unused for this long are evicted - zero uses DEFAULT_POOL_IDLE_TIMEOUT
+	Clock       Clock         // optional - only needed for testing
+}
+
+// ClientPool holds a Client per tenant, for services that send batches on behalf of many companies.
+// - every Client shares one transport, so connections to the API are reused across tenants
+// - each tenant's requests are limited by its own budget, and by a budget shared by all tenants
+// - Clients that haven't been used for a while are evicted, and rebuilt with fresh credentials when next needed
+type ClientPool struct {
+	cfg       PoolConfig
+	transport *http.Transport
+	total     RateLimiter // nil if there's no total budget
+
+	lock      sync.Mutex
+	clients   map[string]*pooledClient
+	lastSweep time.Time
+}
+
+// pooledClient is a tenant's Client, and what's needed to tell if it's idle
+type pooledClient struct {
+	client   *Client
+	lastUsed time.Time
+	active   int // calls through the pool in progress
+}
+
+// NewClientPool builds a new ClientPool
+func NewClientPool(cfg PoolConfig) (*ClientPool, error) {
+	if cfg.Lookup == nil {
+		return nil, fmt.Errorf("ClientPool needs a CredentialsLookup")
+	}
+	if cfg.TenantRequestsPerSecond <= 0 {
+		cfg.TenantRequestsPerSecond = DEFAULT_REQUESTS_PER_SECOND
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DEFAULT_POOL_IDLE_TIMEOUT
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
+
+	// the pool supplies each Client's transport and credentials, so shared options can't
+	probe := clientConfig{client: &Client{}}
+	for _, opt := range cfg.Options {
+		if err := opt(&probe); err != nil {
+			return nil, fmt.Errorf("Invalid ClientPool option: %w", err)
+		}
+	}
+	if probe.httpClient != nil || probe.roundTripper != nil {
+		return nil, fmt.Errorf("ClientPool options cannot include WithHTTPClient or WithRoundTripper - set PoolConfig.Transport instead")
+	}
+	if probe.tlsConfig != nil {
+		return nil, fmt.Errorf("ClientPool options cannot include WithTLSConfig, WithCACertFile or WithClientCertFiles - configure TLS on PoolConfig.Transport instead")
+	}
+	if probe.client.UsrEmail != "" || probe.client.UsrToken != "" || probe.client.auth != nil {
+		return nil, fmt.Errorf("ClientPool options cannot include WithCredentials or WithAuthenticator - tenant credentials come from the Lookup")
+	}
+
+	p := &ClientPool{
+		cfg:       cfg,
+		transport: cfg.Transport,
+		clients:   make(map[string]*pooledClient),
+		lastSweep: cfg.Clock.Now(),
+	}
+	if p.transport == nil {
+		p.transport = newDefaultTransport()
+	}
+	if cfg.TotalRequestsPerSecond > 0 {
+		p.total = p.newLimiter(cfg.TotalRequestsPerSecond, cfg.TotalBurst)
+	}
+	return p, nil
+}
+
+func (p *ClientPool) newLimiter(requestsPerSecond float64, burst int) *TokenBucketLimiter {
+	limiter := NewTokenBucketLimiter(requestsPerSecond, burst, 0, 0)
+	limiter.SetClock(p.cfg.Clock)
+	return limiter
+}
+
+// Client returns the tenant's Client, building it if needed. Clients returned here may be evicted while
+// they're in use, which is harmless, but the tenant's next Client gets a fresh budget - prefer the pool's
+// own methods, which keep the Client while they run.
+func (p *ClientPool) Client(ctx context.Context, tenantID string) (*Client, error) {
+	pooled, err := p.acquire(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	p.release(pooled)
+	return pooled.client, nil
+}
+
+// SendBatch sends a batch with the tenant's Client - see Client.SendBatch
+func (p *ClientPool) SendBatch(ctx context.Context, tenantID string, url string, batch *TagBatchPart) (*SendBatchResult, error) {
+	pooled, err := p.acquire(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(pooled)
+
+	return pooled.client.SendBatch(ctx, url, batch)
+}
+
+// EnsureDimensions creates any missing custom dimensions with the tenant's Client - see Client.EnsureDimensions
+func (p *ClientPool) EnsureDimensions(ctx context.Context, tenantID string, apiHost string, required map[string]string) (int, error) {
+	pooled, err := p.acquire(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer p.release(pooled)
+
+	return pooled.client.EnsureDimensions(ctx, apiHost, required)
+}
+
+// Evict drops the tenant's Client, so it's rebuilt with freshly looked-up credentials when next needed
+func (p *ClientPool) Evict(tenantID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.clients, tenantID)
+}
+
+// EvictIdle drops every Client that's been idle for longer than the idle timeout, returning how many were
+// dropped. It's called as the pool is used, so it's only needed to free idle Clients sooner.
+func (p *ClientPool) EvictIdle() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.evictIdleLocked(p.cfg.Clock.Now())
+}
+
+// Len returns how many tenants have a Client in the pool
+func (p *ClientPool) Len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.clients)
+}
+
+// Close drops every Client, and closes the shared transport's idle connections
+func (p *ClientPool) Close() {
+	p.lock.Lock()
+	p.clients = make(map[string]*pooledClient)
+	p.lock.Unlock()
+
+	p.transport.CloseIdleConnections()
+}
+
+// acquire returns the tenant's Client, building it if needed, and marks it as in use until it's released
+func (p *ClientPool) acquire(ctx context.Context, tenantID string) (*pooledClient, error) {
+	p.lock.Lock()
+	now := p.cfg.Clock.Now()
+	if now.Sub(p.lastSweep) >= p.cfg.IdleTimeout/2 {
+		p.evictIdleLocked(now)
+	}
+	if pooled, ok := p.clients[tenantID]; ok {
+		pooled.active++
+		pooled.lastUsed = now
+		p.lock.Unlock()
+		return pooled, nil
+	}
+	p.lock.Unlock()
+
+	// look up credentials without the lock, since it may be slow
+	client, err := p.newClient(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pooled, ok := p.clients[tenantID]
+	if !ok {
+		// nobody beat us to it
+		pooled = &pooledClient{client: client}
+		p.clients[tenantID] = pooled
+	}
+	pooled.active++
+	pooled.lastUsed = p.cfg.Clock.Now()
+	return pooled, nil
+}
+
+// release marks a Client as no longer in use by the caller
+func (p *ClientPool) release(pooled *pooledClient) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pooled.active--
+	pooled.lastUsed = p.cfg.Clock.Now()
+}
+
+func (p *ClientPool) evictIdleLocked(now time.Time) int {
+	p.lastSweep = now
+	evicted := 0
+	for tenantID, pooled := range p.clients {
+		if pooled.active == 0 && now.Sub(pooled.lastUsed) >= p.cfg.IdleTimeout {
+			delete(p.clients, tenantID)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// newClient builds a Client for the tenant, with its credentials and budget
+func (p *ClientPool) newClient(ctx context.Context, tenantID string) (*Client, error) {
+	creds, err := p.cfg.Lookup.TenantCredentials(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("Error looking up credentials for tenant %s: %w", tenantID, err)
+	}
+	if creds.Token == "" {
+		return nil, fmt.Errorf("Credentials lookup returned an empty token for tenant %s", tenantID)
+	}
+
+	var limiter RateLimiter = p.newLimiter(p.cfg.TenantRequestsPerSecond, p.cfg.TenantBurst)
+	if p.total != nil {
+		limiter = limiterChain{limiter, p.total}
+	}
+
+	opts := make([]Option, 0, len(p.cfg.Options)+3)
+	opts = append(opts, WithRoundTripper(p.transport))
+	opts = append(opts, p.cfg.Options...)
+	opts = append(opts, WithCredentials(creds.Email, creds.Token), WithRateLimiter(limiter))
+	client, err := NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("Error building Client for tenant %s: %w", tenantID, err)
+	}
+	return client, nil
+}
+
+// limiterChain waits for each limiter in turn
+// - if one fails, the ones already waited on get their reservation back, since the request won't be sent
+type limiterChain []RateLimiter
+
+func (l limiterChain) Wait(ctx context.Context, bytes int) error {
+	for i, limiter := range l {
+		if err := limiter.Wait(ctx, bytes); err != nil {
+			for _, waited := range l[:i] {
+				if refunder, ok := waited.(limiterRefunder); ok {
+					refunder.refund(bytes)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package hippo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tenantServer accepts batches, recording the token each request was sent with
+type tenantServer struct {
+	*httptest.Server
+	lock   sync.Mutex
+	tokens []string
+}
+
+func newTenantServer() *tenantServer {
+	s := &tenantServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		s.tokens = append(s.tokens, r.Header.Get("X-CH-Auth-API-Token"))
+		s.lock.Unlock()
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	return s
+}
+
+// countingLookup hands out a token per tenant, counting lookups
+type countingLookup struct {
+	lock    sync.Mutex
+	lookups map[string]int
+}
+
+func (l *countingLookup) TenantCredentials(ctx context.Context, tenantID string) (Credentials, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if tenantID == "unknown" {
+		return Credentials{}, errors.New("no such tenant")
+	}
+	l.lookups[tenantID]++
+	return Credentials{Email: tenantID + "@example.com", Token: "token-" + tenantID}, nil
+}
+
+func TestClientPool_SendBatch(t *testing.T) {
+	a := require.New(t)
+
+	server := newTenantServer()
+	defer server.Close()
+
+	lookup := &countingLookup{lookups: make(map[string]int)}
+	pool, err := NewClientPool(PoolConfig{Lookup: lookup, Clock: newFakeClock()})
+	a.NoError(err)
+	defer pool.Close()
+
+	for _, tenantID := range []string{"1", "2", "1"} {
+		_, err := pool.SendBatch(context.Background(), tenantID, server.URL, buildTestBatch(1, false))
+		a.NoError(err)
+	}
+	a.Equal([]string{"token-1", "token-2", "token-1"}, server.tokens)
+	a.Equal(map[string]int{"1": 1, "2": 1}, lookup.lookups)
+	a.Equal(2, pool.Len())
+
+	// clients share the transport
+	first, err := pool.Client(context.Background(), "1")
+	a.NoError(err)
+	second, err := pool.Client(context.Background(), "2")
+	a.NoError(err)
+	a.True(first.http.Transport == second.http.Transport)
+
+	_, err = pool.SendBatch(context.Background(), "unknown", server.URL, buildTestBatch(1, false))
+	a.EqualError(err, "Error looking up credentials for tenant unknown: no such tenant")
+
+	_, err = NewClientPool(PoolConfig{})
+	a.Error(err)
+}
+
+func TestClientPool_Budgets(t *testing.T) {
+	a := require.New(t)
+
+	server := newTenantServer()
+	defer server.Close()
+
+	// each tenant gets one request a second
+	clock := newFakeClock()
+	lookup := &countingLookup{lookups: make(map[string]int)}
+	pool, err := NewClientPool(PoolConfig{Lookup: lookup, Clock: clock, TenantRequestsPerSecond: 1})
+	a.NoError(err)
+
+	for _, tenantID := range []string{"1", "2", "1"} {
+		_, err := pool.SendBatch(context.Background(), tenantID, server.URL, buildTestBatch(1, false))
+		a.NoError(err)
+	}
+	a.Equal([]time.Duration{time.Second}, clock.sleeps)
+
+	// and all of them together get one request every two seconds
+	clock = newFakeClock()
+	pool, err = NewClientPool(PoolConfig{Lookup: lookup, Clock: clock, TenantRequestsPerSecond: 10, TotalRequestsPerSecond: 0.5})
+	a.NoError(err)
+
+	for _, tenantID := range []string{"1", "2"} {
+		_, err := pool.SendBatch(context.Background(), tenantID, server.URL, buildTestBatch(1, false))
+		a.NoError(err)
+	}
+	a.Equal([]time.Duration{2 * time.Second}, clock.sleeps)
+}
+
+func TestClientPool_EvictIdle(t *testing.T) {
+	a := require.New(t)
+
+	server := newTenantServer()
+	defer server.Close()
+
+	clock := newFakeClock()
+	lookup := &countingLookup{lookups: make(map[string]int)}
+	pool, err := NewClientPool(PoolConfig{Lookup: lookup, Clock: clock, IdleTimeout: time.Minute})
+	a.NoError(err)
+
+	_, err = pool.Client(context.Background(), "1")
+	a.NoError(err)
+	clock.Advance(30 * time.Second)
+	_, err = pool.Client(context.Background(), "2")
+	a.NoError(err)
+	a.Equal(0, pool.EvictIdle())
+
+	clock.Advance(30 * time.Second)
+	a.Equal(1, pool.EvictIdle())
+	a.Equal(1, pool.Len())
+
+	// idle clients are swept as the pool is used, and rebuilt when needed again
+	clock.Advance(time.Minute)
+	_, err = pool.Client(context.Background(), "1")
+	a.NoError(err)
+	a.Equal(1, pool.Len())
+	a.Equal(map[string]int{"1": 2, "2": 1}, lookup.lookups)
+
+	pool.Evict("1")
+	a.Equal(0, pool.Len())
+}
+
+func TestClientPool_EnsureDimensions(t *testing.T) {
+	a := require.New(t)
+
+	server := newDimensionServer(a, &CustomDimension{ID: 1, Name: "c_existing"})
+	defer server.Close()
+
+	lookup := &countingLookup{lookups: make(map[string]int)}
+	pool, err := NewClientPool(PoolConfig{Lookup: lookup, Clock: newFakeClock(), Options: []Option{WithBaseURL(server.URL)}})
+	a.NoError(err)
+
+	created, err := pool.EnsureDimensions(context.Background(), "1", "", map[string]string{"c_existing": "Existing", "c_new": "New"})
+	a.NoError(err)
+	a.Equal(1, created)
+}
+
+func TestNewClientPool_InvalidOptions(t *testing.T) {
+	a := require.New(t)
+
+	lookup := &countingLookup{lookups: make(map[string]int)}
+	for _, opt := range []Option{
+		WithCredentials("shared@example.com", "shared"),
+		WithAuthenticator(EmailTokenAuth{Email: "shared@example.com", Token: "shared"}),
+		WithRoundTripper(http.DefaultTransport),
+		WithHTTPClient(&http.Client{}),
+		WithTLSConfig(&tls.Config{}),
+	} {
+		_, err := NewClientPool(PoolConfig{Lookup: lookup, Options: []Option{opt}})
+		a.Error(err)
+	}
+}
+
+// failingLimiter never lets a request through
+type failingLimiter struct{}
+
+func (failingLimiter) Wait(ctx context.Context, bytes int) error {
+	return errors.New("over budget")
+}
+
+// Test that the tenant's budget isn't spent on a request the total budget stopped
+func TestLimiterChain_Refund(t *testing.T) {
+	a := require.New(t)
+
+	clock := newFakeClock()
+	tenant := NewTokenBucketLimiter(1, 1, 0, 0)
+	tenant.SetClock(clock)
+
+	a.Error(limiterChain{tenant, failingLimiter{}}.Wait(context.Background(), 100))
+	a.NoError(tenant.Wait(context.Background(), 100))
+	a.Empty(clock.sleeps)
+}
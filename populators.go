@@ -0,0 +1,197 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dimensionPopulatorsResponse is the server's response to a request for a single custom dimension, with only
+// its populators
+type dimensionPopulatorsResponse struct {
+	Dimension *struct {
+		Populators []apiPopulator `json:"populators"`
+	} `json:"customDimension"`
+}
+
+// apiPopulator is a populator as the custom dimension endpoint returns it. Unlike in a batch, each criteria
+// field is a single string, with multiple values separated by commas. Fields we don't compare, like the
+// populator's ID and dates, are ignored.
+type apiPopulator struct {
+	Value         string         `json:"value"`
+	Direction     string         `json:"direction"`
+	DeviceName    populatorField `json:"device_name"`
+	InterfaceName populatorField `json:"interface_name"`
+	Addr          populatorField `json:"addr"`
+	Port          populatorField `json:"port"`
+	TCPFlags      populatorField `json:"tcp_flags"`
+	Protocol      populatorField `json:"protocol"`
+	ASN           populatorField `json:"asn"`
+	NextHopASN    populatorField `json:"nexthop_asn"`
+	NextHop       populatorField `json:"nexthop"`
+	BGPASPath     populatorField `json:"bgp_aspath"`
+	BGPCommunity  populatorField `json:"bgp_community"`
+	DeviceType    populatorField `json:"device_type"`
+	Site          populatorField `json:"site"`
+	LastHopASName populatorField `json:"lasthop_as_name"`
+	NextHopASName populatorField `json:"nexthop_as_name"`
+	MAC           populatorField `json:"mac"`
+	Country       populatorField `json:"country"`
+	VLANs         populatorField `json:"vlans"`
+}
+
+// criteria converts the populator's fields to criteria, in the same format as in a batch
+func (p *apiPopulator) criteria() (TagCriteria, error) {
+	criteria := TagCriteria{
+		Direction:            p.Direction,
+		DeviceNameRegexes:    p.DeviceName.list(),
+		InterfaceNameRegexes: p.InterfaceName.list(),
+		IPAddresses:          p.Addr.list(),
+		PortRanges:           p.Port.list(),
+		ASNRanges:            p.ASN.list(),
+		NextHopASNRanges:     p.NextHopASN.list(),
+		NextHopIPAddresses:   p.NextHop.list(),
+		BGPASPaths:           p.BGPASPath.list(),
+		BGPCommunities:       p.BGPCommunity.list(),
+		DeviceTypeRegexes:    p.DeviceType.list(),
+		SiteNameRegexes:      p.Site.list(),
+		LastHopASNNames:      p.LastHopASName.list(),
+		NextHopASNNames:      p.NextHopASName.list(),
+		MACAddresses:         p.MAC.list(),
+		CountryCodes:         p.Country.list(),
+		VLanRanges:           p.VLANs.list(),
+	}
+	for _, protocol := range p.Protocol.list() {
+		parsed, err := strconv.ParseUint(protocol, 10, 32)
+		if err != nil {
+			return criteria, fmt.Errorf("Invalid protocol '%s'", protocol)
+		}
+		criteria.Protocols = append(criteria.Protocols, uint32(parsed))
+	}
+	if p.TCPFlags != "" {
+		parsed, err := strconv.ParseUint(strings.TrimSpace(string(p.TCPFlags)), 10, 32)
+		if err != nil {
+			return criteria, fmt.Errorf("Invalid TCP flags '%s'", p.TCPFlags)
+		}
+		criteria.TCPFlags = uint32(parsed)
+	}
+	return criteria, nil
+}
+
+// populatorField is a populator criteria field - a string of comma-separated values, which the server may send
+// as a number, or null, when it's numeric or empty
+type populatorField string
+
+func (f *populatorField) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = ""
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*f = populatorField(str)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return err
+	}
+	*f = populatorField(num.String())
+	return nil
+}
+
+// list splits the field into its values, returning nil if it's empty
+func (f populatorField) list() []string {
+	var ret []string
+	for _, value := range strings.Split(string(f), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			ret = append(ret, value)
+		}
+	}
+	return ret
+}
+
+// FetchPopulators reads the populators currently deployed for the custom dimension with the given name, rebuilding
+// them into a batch with one upsert per value. The batch is normalized with NormalizeTagBatchPart, so it can be
+// compared with a local batch normalized the same way.
+//   - the API doesn't page populators: the custom dimension endpoint returns every one of them in one response,
+//     which is read into memory whole, so a dimension with hundreds of thousands of populators needs memory to match
+//   - returns an error matching ErrNotFound if the company has no such dimension
+func (c *Client) FetchPopulators(ctx context.Context, dimensionName string) (*TagBatchPart, error) {
+	if err := ValidateDimensionName(dimensionName); err != nil {
+		return nil, err
+	}
+	dimension, err := c.GetDimensionByName(ctx, dimensionName)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dimensionPopulatorsResponse{}
+	if err := c.doJSON(ctx, "GET", c.Endpoints().Dimension(dimension.ID), nil, &response); err != nil {
+		return nil, fmt.Errorf("Error fetching populators of custom dimension '%s': %w", dimensionName, err)
+	}
+	if response.Dimension == nil {
+		return nil, fmt.Errorf("API response did not include custom dimension %d", dimension.ID)
+	}
+
+	batch := NewTagBatch()
+	batch.IsComplete = true
+	for i := range response.Dimension.Populators {
+		populator := &response.Dimension.Populators[i]
+		criteria, err := populator.criteria()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading criteria of populator '%s': %s", populator.Value, err)
+		}
+		batch.Upserts = append(batch.Upserts, TagUpsert{
+			Value:    populator.Value,
+			Criteria: []TagCriteria{criteria},
+		})
+	}
+
+	// group the criteria by value, the same way SendBatch does
+	ret := compactTagBatchPart(batch)
+	NormalizeTagBatchPart(ret)
+	return ret, nil
+}
+
+// NormalizeTagBatchPart normalizes every upsert's criteria, and sorts them, so batches with the same
+// populators compare equal, whatever order they were built in.
+// - criteria are sorted by their JSON, upserts and deletes by value
+func NormalizeTagBatchPart(batch *TagBatchPart) {
+	for i := range batch.Upserts {
+		upsert := &batch.Upserts[i]
+
+		// copy the criteria, since compacted batches share them with the original
+		type keyedCriteria struct {
+			key      string
+			criteria TagCriteria
+		}
+		keyed := make([]keyedCriteria, len(upsert.Criteria))
+		for j := range upsert.Criteria {
+			keyed[j].criteria = upsert.Criteria[j]
+			keyed[j].criteria.Normalize()
+			if keyBytes, err := json.Marshal(&keyed[j].criteria); err == nil {
+				keyed[j].key = string(keyBytes)
+			}
+		}
+		sort.SliceStable(keyed, func(a, b int) bool {
+			return keyed[a].key < keyed[b].key
+		})
+
+		criteria := make([]TagCriteria, len(keyed))
+		for j := range keyed {
+			criteria[j] = keyed[j].criteria
+		}
+		upsert.Criteria = criteria
+	}
+
+	sort.SliceStable(batch.Upserts, func(i, j int) bool {
+		return batch.Upserts[i].Value < batch.Upserts[j].Value
+	})
+	sort.SliceStable(batch.Deletes, func(i, j int) bool {
+		return batch.Deletes[i].Value < batch.Deletes[j].Value
+	})
+}
@@ -0,0 +1,72 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_FetchPopulators(t *testing.T) {
+	a := require.New(t)
+
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("GET", r.Method)
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/internal/customdimensions":
+			_, _ = w.Write([]byte(`{"customDimensions":[{"id":7,"name":"c_customer"},{"id":8,"name":"c_other"}]}`))
+		case "/api/internal/customdimension/7":
+			// populators as the API returns them, with comma-separated criteria
+			_, _ = w.Write([]byte(`{"customDimension":{"id":7,"name":"c_customer","display_name":"Customer","type":"string","populators":[
+				{"id":1,"dimension_id":7,"value":"b","direction":"SRC","addr":"10.0.0.2,10.0.0.1","addr_count":2,"port":null,
+				 "protocol":"","tcp_flags":"","device_name":"","created_date":"2026-01-01T00:00:00.000Z","company_id":"1"},
+				{"id":2,"dimension_id":7,"value":"a","direction":"DST","addr":"192.168.0.0/16","addr_count":1,
+				 "protocol":"6, 17","tcp_flags":2,"device_name":"router1,router2","created_date":"2026-01-01T00:00:00.000Z","company_id":"1"},
+				{"id":3,"dimension_id":7,"value":"B","direction":"EITHER","addr":"","port":"80,443","asn":"64512",
+				 "created_date":"2026-01-01T00:00:00.000Z","company_id":"1"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	a.NoError(err)
+
+	batch, err := client.FetchPopulators(context.Background(), "c_customer")
+	a.NoError(err)
+	a.Equal([]string{"/api/internal/customdimensions", "/api/internal/customdimension/7"}, requested)
+
+	// a local batch with the same populators, in a different order, compares equal once normalized
+	local := &TagBatchPart{
+		IsComplete: true,
+		Upserts: []TagUpsert{
+			{Value: "a", Criteria: []TagCriteria{{
+				Direction:         "dst",
+				IPAddresses:       []string{"192.168.0.0/16"},
+				Protocols:         []uint32{17, 6},
+				TCPFlags:          2,
+				DeviceNameRegexes: []string{"router2", "router1"},
+			}}},
+			{Value: "B", Criteria: []TagCriteria{
+				{PortRanges: []string{"443", "80"}, ASNRanges: []string{"64512"}},
+				{Direction: "src", IPAddresses: []string{"10.0.0.1", "10.0.0.2"}},
+			}},
+		},
+		Deletes: make([]TagDelete, 0),
+	}
+	NormalizeTagBatchPart(local)
+	a.Equal(local, batch)
+	a.Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}, batch.Upserts[0].Criteria[1].IPAddresses)
+
+	_, err = client.FetchPopulators(context.Background(), "c_missing")
+	a.True(errors.Is(err, ErrNotFound))
+
+	_, err = client.FetchPopulators(context.Background(), "customer")
+	a.True(errors.Is(err, ErrValidation))
+}
@@ -0,0 +1,48 @@
+package hippo
+
+import (
+	"time"
+)
+
+// PartResult describes a batch part that the server accepted
+type PartResult struct {
+	Index       int           // index of the part, starting at 0 - the halves of a split part have their own indexes
+	UpsertCount int           // upserts in the part
+	DeleteCount int           // deletes in the part
+	RawBytes    int           // size of the part's JSON
+	BodyBytes   int           // size of the request body, after compression
+	Attempts    int           // how many times the part was POSTed, counting retries
+	Latency     time.Duration // how long the accepted request took, not counting time waiting for the rate limiter
+	Message     string        // the server's message, if it sent one
+	Complete    bool          // whether the part completed the batch
+}
+
+// PartFailure describes a batch part that the server didn't accept, or whose response showed it failed
+type PartFailure struct {
+	Index       int    // index of the part, starting at 0
+	UpsertCount int    // upserts in the part
+	DeleteCount int    // deletes in the part
+	Attempts    int    // how many times the part was POSTed, counting retries
+	Message     string // the server's message, if it sent one
+	Err         error
+}
+
+// ProgressFunc is called as each part of a batch is accepted, with the part, and the progress so far.
+// Calls are made one at a time, but parts sent concurrently may be reported out of order. Keep it fast,
+// since other parts wait for it to return before they're recorded.
+type ProgressFunc func(part PartResult, progress SendBatchResult)
+
+// SendBatchOptions are optional settings for sending one batch
+type SendBatchOptions struct {
+	Progress     ProgressFunc // optional - called as each part is accepted
+	CheckpointID string       // unique to the batch - it's only checkpointed with one, if the Client has a CheckpointStore
+	AllowShrink  bool         // sends a replace-all batch even if the Client's ShrinkGuard would refuse it
+}
+
+// ProgressChan returns a ProgressFunc that sends each part to the channel, blocking while the channel is full.
+// The channel isn't closed - close it once the send returns, if needed.
+func ProgressChan(parts chan<- PartResult) ProgressFunc {
+	return func(part PartResult, progress SendBatchResult) {
+		parts <- part
+	}
+}
@@ -0,0 +1,74 @@
+package hippo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendBatchWithOptions_Progress(t *testing.T) {
+	a := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = getJSON(a, r)
+		time.Sleep(5 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"guid":"abc","message":"accepted"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithMaxPartSize(300))
+	a.NoError(err)
+
+	reported := make([]PartResult, 0)
+	upsertsSent := make([]int, 0)
+	result, err := client.SendBatchWithOptions(context.Background(), server.URL, buildTestBatch(10, true), SendBatchOptions{
+		Progress: func(part PartResult, progress SendBatchResult) {
+			reported = append(reported, part)
+			upsertsSent = append(upsertsSent, progress.UpsertsSent)
+			a.Equal(10, progress.UpsertsTotal)
+		},
+	})
+	a.NoError(err)
+	a.True(result.PartsSent > 1)
+	a.Equal(result.Parts, reported)
+
+	upserts := 0
+	for i, part := range result.Parts {
+		upserts += part.UpsertCount
+		a.Equal(i, part.Index)
+		a.Equal(upserts, upsertsSent[i])
+		a.Equal("accepted", part.Message)
+		a.Equal(1, part.Attempts)
+		a.True(part.Latency >= 5*time.Millisecond, part.Latency)
+		a.True(part.BodyBytes > 0)
+		a.True(part.RawBytes > part.BodyBytes)
+		a.Equal(i == len(result.Parts)-1, part.Complete)
+	}
+	a.Equal(10, upserts)
+}
+
+func TestSendBatchStream_ProgressChan(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	client, err := NewClient(WithMaxPartSize(300))
+	a.NoError(err)
+
+	parts := make(chan PartResult, 100)
+	result, err := client.SendBatchStream(context.Background(), server.URL, StreamOptions{Progress: ProgressChan(parts)}, sliceStream(buildTestBatch(10, false)))
+	a.NoError(err)
+	close(parts)
+
+	count := 0
+	for range parts {
+		count++
+	}
+	a.Equal(result.PartsSent, count)
+	a.Equal(result.PartsSent, len(result.Parts))
+}
@@ -0,0 +1,365 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_QUEUE_RETRY_INTERVAL = 30 * time.Second
+	DEFAULT_QUEUE_DEAD_LETTER    = "rejected" // subdirectory of the queue's directory
+)
+
+// QueueOptions configures an OfflineQueue
+type QueueOptions struct {
+	RetryInterval time.Duration // how long to wait after a send fails - zero uses DEFAULT_QUEUE_RETRY_INTERVAL
+	DeadLetterDir string        // where rejected batches are moved - empty uses DEFAULT_QUEUE_DEAD_LETTER in the queue's directory
+}
+
+// QueueStats describes what's waiting in an OfflineQueue
+type QueueStats struct {
+	Depth      int           // batches waiting to be sent
+	OldestAge  time.Duration // how long the oldest batch has been waiting - zero if the queue's empty
+	Sent       int           // batches sent since the queue was opened
+	Superseded int           // batches dropped since the queue was opened, because a newer replace-all batch replaced them
+	Dropped    int           // batches moved to the dead letter directory since the queue was opened, because they were rejected
+}
+
+// EnqueueOptions are optional settings for one queued batch
+type EnqueueOptions struct {
+	AllowShrink bool // sends a replace-all batch even if the Client's ShrinkGuard would refuse it
+}
+
+// queuedBatch is a batch waiting in an OfflineQueue, as it's saved to disk
+type queuedBatch struct {
+	Seq         uint64        `json:"seq"`
+	URL         string        `json:"url"`
+	Enqueued    time.Time     `json:"enqueued"`
+	Batch       *TagBatchPart `json:"batch"`
+	AllowShrink bool          `json:"allow_shrink,omitempty"`
+}
+
+// OfflineQueue is a durable on-disk queue of batches, for when the API may be unreachable for a while.
+// Batches are saved to a directory as they're enqueued, and sent in order by Run, surviving restarts.
+//   - enqueuing a replace-all batch drops any batches queued earlier for the same URL, since it replaces them anyway
+//   - batches that fail our own checks, or that the server rejects as invalid (see ErrValidation), would never
+//     succeed, so they're moved to the dead letter directory; every other failure is retried
+//   - that includes replace-all batches the Client's ShrinkGuard refuses - enqueue an intended shrink with AllowShrink
+//   - if the Client has a CheckpointStore, a batch that fails partway through is resumed under its batch GUID
+type OfflineQueue struct {
+	client *Client
+	dir    string
+	opts   QueueOptions
+	wake   chan struct{} // signalled when a batch is enqueued
+
+	lock       sync.Mutex
+	entries    []*queuedBatch // in the order they're sent
+	sending    *queuedBatch   // the batch Drain is sending - its files are left alone until it's done
+	nextSeq    uint64
+	sent       int
+	superseded int
+	dropped    int
+}
+
+// NewOfflineQueue opens the queue in the directory, creating it if needed, and loading any batches already in it
+func NewOfflineQueue(client *Client, dir string, opts QueueOptions) (*OfflineQueue, error) {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DEFAULT_QUEUE_RETRY_INTERVAL
+	}
+	if opts.DeadLetterDir == "" {
+		opts.DeadLetterDir = filepath.Join(dir, DEFAULT_QUEUE_DEAD_LETTER)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating queue directory: %s", err)
+	}
+	if err := os.MkdirAll(opts.DeadLetterDir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating dead letter directory: %s", err)
+	}
+
+	q := &OfflineQueue{
+		client:  client,
+		dir:     dir,
+		opts:    opts,
+		wake:    make(chan struct{}, 1),
+		entries: make([]*queuedBatch, 0),
+		nextSeq: 1,
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load reads the batches saved in the queue's directory
+func (q *OfflineQueue) load() error {
+	files, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("Error reading queue directory: %s", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		entryBytes, err := ioutil.ReadFile(filepath.Join(q.dir, file.Name()))
+		if err != nil {
+			return fmt.Errorf("Error reading queued batch: %s", err)
+		}
+		entry := &queuedBatch{}
+		if err := json.Unmarshal(entryBytes, entry); err != nil {
+			return fmt.Errorf("Error unmarshalling queued batch %s: %s", file.Name(), err)
+		}
+		q.entries = append(q.entries, entry)
+		if entry.Seq >= q.nextSeq {
+			q.nextSeq = entry.Seq + 1
+		}
+	}
+
+	sort.Slice(q.entries, func(i, j int) bool {
+		return q.entries[i].Seq < q.entries[j].Seq
+	})
+	return nil
+}
+
+// Enqueue saves a batch to be sent to the URL. It returns once the batch is on disk.
+// - the queue sends what's on disk, so changing the batch afterwards doesn't change what's sent
+func (q *OfflineQueue) Enqueue(url string, batch *TagBatchPart) error {
+	return q.EnqueueWithOptions(url, batch, EnqueueOptions{})
+}
+
+// EnqueueWithOptions works like Enqueue, with options for this batch
+func (q *OfflineQueue) EnqueueWithOptions(url string, batch *TagBatchPart, opts EnqueueOptions) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	entryBytes, err := json.Marshal(&queuedBatch{
+		Seq:         q.nextSeq,
+		URL:         url,
+		Enqueued:    time.Now(),
+		Batch:       batch,
+		AllowShrink: opts.AllowShrink,
+	})
+	if err != nil {
+		return fmt.Errorf("Error marshalling batch: %s", err)
+	}
+	entry := &queuedBatch{}
+	if err := json.Unmarshal(entryBytes, entry); err != nil {
+		return fmt.Errorf("Error unmarshalling batch: %s", err)
+	}
+	if err := writeFileAtomic(q.path(entry), entryBytes); err != nil {
+		return fmt.Errorf("Error writing queued batch: %s", err)
+	}
+	q.nextSeq++
+
+	if batch.ReplaceAll {
+		// anything already queued for the URL is replaced by this batch
+		kept := make([]*queuedBatch, 0, len(q.entries)+1)
+		removed := make([]*queuedBatch, 0)
+		for _, queued := range q.entries {
+			if queued.URL != url {
+				kept = append(kept, queued)
+			} else {
+				removed = append(removed, queued)
+			}
+		}
+		q.entries = kept
+		q.superseded += len(removed)
+
+		// the new batch is already on disk, so a file that can't be removed is only logged - at worst the
+		// superseded batch is loaded again after a restart, and sent before the batch that replaces it
+		for _, queued := range removed {
+			if queued == q.sending {
+				// Drain removes it once the send is done, so the send doesn't checkpoint under a removed ID
+				continue
+			}
+			if err := q.removeFile(queued); err != nil {
+				q.client.logger().Warn("error removing superseded queued batch", "url", queued.URL, "enqueued", queued.Enqueued, "error", err)
+			}
+		}
+	}
+	q.entries = append(q.entries, entry)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Stats returns the queue's depth and age
+func (q *OfflineQueue) Stats() QueueStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	stats := QueueStats{
+		Depth:      len(q.entries),
+		Sent:       q.sent,
+		Superseded: q.superseded,
+		Dropped:    q.dropped,
+	}
+	if len(q.entries) > 0 {
+		stats.OldestAge = time.Since(q.entries[0].Enqueued)
+	}
+	return stats
+}
+
+// Run sends queued batches until the context is done, waiting RetryInterval after a failure, and for new
+// batches once the queue's empty. Run it in its own goroutine; only one Run or Drain may be going at once.
+func (q *OfflineQueue) Run(ctx context.Context) error {
+	for {
+		err := q.Drain(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-q.wake:
+			}
+			continue
+		}
+
+		// a new batch doesn't mean the API's back, so only wait for the retry
+		q.client.logger().Warn("error sending queued batch, will retry", "retry_interval", q.opts.RetryInterval, "error", err)
+		timer := time.NewTimer(q.opts.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Drain sends queued batches in order until the queue is empty, or until one fails and needs to be retried
+func (q *OfflineQueue) Drain(ctx context.Context) error {
+	for {
+		q.lock.Lock()
+		if len(q.entries) == 0 {
+			q.lock.Unlock()
+			return nil
+		}
+		entry := q.entries[0]
+		q.sending = entry
+		q.lock.Unlock()
+
+		err := q.send(ctx, entry)
+
+		q.lock.Lock()
+		q.sending = nil
+		var removeErr error
+		switch {
+		case !q.queuedLocked(entry):
+			// superseded while it was being sent, which left its files for us
+			removeErr = q.removeFile(entry)
+		case err == nil:
+			q.removeEntryLocked(entry)
+			removeErr = q.removeFile(entry)
+			q.sent++
+		case rejectedBatch(err):
+			q.client.logger().Error("moving rejected queued batch to the dead letter directory", "url", entry.URL, "enqueued", entry.Enqueued, "error", err)
+			q.removeEntryLocked(entry)
+			removeErr = q.deadLetter(entry)
+			q.dropped++
+		default:
+			q.lock.Unlock()
+			return err
+		}
+		q.lock.Unlock()
+		if removeErr != nil {
+			return removeErr
+		}
+	}
+}
+
+// rejectedBatch returns whether a queued batch failed in a way that re-sending won't fix - it failed our own
+// checks before it was sent, or the server rejected it as invalid. Anything else, like a 404 from a proxy, is retried.
+func rejectedBatch(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+// send sends a queued batch, resuming it from its checkpoint if it has one
+func (q *OfflineQueue) send(ctx context.Context, entry *queuedBatch) error {
+	// the batch's file is unique to it, even if several queues share the CheckpointStore
+	checkpointID := q.path(entry)
+	if store := q.checkpointStore(); store != nil {
+		checkpoint, err := store.Load(checkpointID)
+		if err == nil {
+			_, err = q.client.ResumeBatch(ctx, checkpoint)
+			return err
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	_, err := q.client.SendBatchWithOptions(ctx, entry.URL, entry.Batch, SendBatchOptions{CheckpointID: checkpointID, AllowShrink: entry.AllowShrink})
+	return err
+}
+
+// queuedLocked returns whether a batch is still queued - it isn't once it's been superseded
+func (q *OfflineQueue) queuedLocked(entry *queuedBatch) bool {
+	for _, queued := range q.entries {
+		if queued == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEntryLocked removes a batch from the queue, leaving its files alone
+func (q *OfflineQueue) removeEntryLocked(entry *queuedBatch) {
+	for i, queued := range q.entries {
+		if queued == entry {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// deadLetter moves a rejected batch's file to the dead letter directory, dropping its checkpoint
+func (q *OfflineQueue) deadLetter(entry *queuedBatch) error {
+	if store := q.checkpointStore(); store != nil {
+		if err := store.Delete(q.path(entry)); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(q.path(entry), filepath.Join(q.opts.DeadLetterDir, filepath.Base(q.path(entry)))); err != nil {
+		return fmt.Errorf("Error moving rejected batch to the dead letter directory: %s", err)
+	}
+	return nil
+}
+
+// removeFile removes a batch's file, along with its checkpoint, if it has one
+func (q *OfflineQueue) removeFile(entry *queuedBatch) error {
+	if store := q.checkpointStore(); store != nil {
+		if err := store.Delete(q.path(entry)); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(q.path(entry)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error removing queued batch: %s", err)
+	}
+	return nil
+}
+
+func (q *OfflineQueue) checkpointStore() CheckpointStore {
+	q.client.lock.RLock()
+	defer q.client.lock.RUnlock()
+
+	return q.client.checkpoints
+}
+
+func (q *OfflineQueue) path(entry *queuedBatch) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d.json", entry.Seq))
+}
@@ -0,0 +1,346 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// queueServer accepts batches while it's up, recording them
+type queueServer struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	status  int // returned while the API's down - zero when it's up
+	batches []TagBatchPart
+}
+
+func newQueueServer(a *require.Assertions) *queueServer {
+	s := &queueServer{status: http.StatusServiceUnavailable}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			return
+		}
+		part := TagBatchPart{}
+		a.NoError(json.Unmarshal(getJSON(a, r), &part))
+		s.batches = append(s.batches, part)
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	return s
+}
+
+func (s *queueServer) setStatus(status int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = status
+}
+
+func (s *queueServer) received() []TagBatchPart {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]TagBatchPart(nil), s.batches...)
+}
+
+func TestOfflineQueue_Drain(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+
+	client, err := NewClient()
+	a.NoError(err)
+	dir := t.TempDir()
+	queue, err := NewOfflineQueue(client, dir, QueueOptions{})
+	a.NoError(err)
+
+	batch := buildTestBatch(3, false)
+	a.NoError(queue.Enqueue(server.URL+"/first", batch))
+	a.NoError(queue.Enqueue(server.URL+"/second", buildTestBatch(1, false)))
+
+	// while the API's down, batches stay queued
+	a.Error(queue.Drain(context.Background()))
+	stats := queue.Stats()
+	a.Equal(2, stats.Depth)
+	a.True(stats.OldestAge > 0)
+	a.Equal(0, stats.Sent)
+
+	// and survive a restart
+	queue, err = NewOfflineQueue(client, dir, QueueOptions{})
+	a.NoError(err)
+	a.Equal(2, queue.Stats().Depth)
+
+	server.setStatus(0)
+	a.NoError(queue.Drain(context.Background()))
+	stats = queue.Stats()
+	a.Equal(0, stats.Depth)
+	a.Equal(time.Duration(0), stats.OldestAge)
+	a.Equal(2, stats.Sent)
+
+	received := server.received()
+	a.Len(received, 2)
+	a.Len(received[0].Upserts, 3)
+	a.ElementsMatch(batch.Upserts, received[0].Upserts)
+	a.Len(received[1].Upserts, 1)
+
+	// new batches continue the sequence
+	a.NoError(queue.Enqueue(server.URL+"/third", buildTestBatch(1, false)))
+	queue, err = NewOfflineQueue(client, dir, QueueOptions{})
+	a.NoError(err)
+	a.Equal(uint64(4), queue.nextSeq)
+}
+
+func TestOfflineQueue_ReplaceAllSupersedes(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+	server.setStatus(0)
+
+	client, err := NewClient()
+	a.NoError(err)
+	queue, err := NewOfflineQueue(client, t.TempDir(), QueueOptions{})
+	a.NoError(err)
+
+	a.NoError(queue.Enqueue(server.URL+"/a", buildTestBatch(1, false)))
+	a.NoError(queue.Enqueue(server.URL+"/b", buildTestBatch(2, true)))
+	a.NoError(queue.Enqueue(server.URL+"/a", buildTestBatch(3, true)))
+	a.NoError(queue.Enqueue(server.URL+"/a", buildTestBatch(4, false)))
+
+	stats := queue.Stats()
+	a.Equal(3, stats.Depth)
+	a.Equal(1, stats.Superseded)
+
+	a.NoError(queue.Drain(context.Background()))
+	received := server.received()
+	a.Len(received, 3)
+	a.Len(received[0].Upserts, 2)
+	a.Len(received[1].Upserts, 3)
+	a.Len(received[2].Upserts, 4)
+}
+
+func TestOfflineQueue_DropsInvalid(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+	server.setStatus(http.StatusBadRequest)
+
+	client, err := NewClient()
+	a.NoError(err)
+	dir := t.TempDir()
+	queue, err := NewOfflineQueue(client, dir, QueueOptions{})
+	a.NoError(err)
+
+	// rejected batches are moved out of the queue, not deleted
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(1, false)))
+	a.NoError(queue.Drain(context.Background()))
+	stats := queue.Stats()
+	a.Equal(0, stats.Depth)
+	a.Equal(1, stats.Dropped)
+	_, err = os.Stat(filepath.Join(dir, DEFAULT_QUEUE_DEAD_LETTER, fmt.Sprintf("%020d.json", 1)))
+	a.NoError(err)
+
+	// so are batches too big to split any further
+	server.setStatus(http.StatusRequestEntityTooLarge)
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(1, false)))
+	a.NoError(queue.Drain(context.Background()))
+	stats = queue.Stats()
+	a.Equal(0, stats.Depth)
+	a.Equal(2, stats.Dropped)
+
+	// a 404 may just be a proxy, so it's retried
+	server.setStatus(http.StatusNotFound)
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(1, false)))
+	a.Error(queue.Drain(context.Background()))
+	stats = queue.Stats()
+	a.Equal(1, stats.Depth)
+	a.Equal(2, stats.Dropped)
+}
+
+// failingDeleteStore is a CheckpointStore that can't delete checkpoints
+type failingDeleteStore struct {
+	CheckpointStore
+}
+
+func (s *failingDeleteStore) Delete(id string) error {
+	return errors.New("delete failed")
+}
+
+// Test that a superseded batch that can't be removed doesn't stop the newer batch being queued
+func TestOfflineQueue_SupersedeRemoveFails(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+	server.setStatus(0)
+
+	store, err := NewFileCheckpointStore(t.TempDir())
+	a.NoError(err)
+	client, err := NewClient(WithCheckpointStore(&failingDeleteStore{store}))
+	a.NoError(err)
+	queue, err := NewOfflineQueue(client, t.TempDir(), QueueOptions{})
+	a.NoError(err)
+
+	a.NoError(queue.Enqueue(server.URL+"/a", buildTestBatch(1, true)))
+	a.NoError(queue.Enqueue(server.URL+"/b", buildTestBatch(2, true)))
+	a.NoError(queue.Enqueue(server.URL+"/a", buildTestBatch(3, true)))
+	a.NoError(queue.Enqueue(server.URL+"/a", buildTestBatch(4, true)))
+
+	stats := queue.Stats()
+	a.Equal(2, stats.Depth)
+	a.Equal(2, stats.Superseded)
+	a.Len(queue.entries, 2)
+	a.Equal(server.URL+"/b", queue.entries[0].URL)
+	a.Len(queue.entries[1].Batch.Upserts, 4)
+}
+
+// Test that an intended shrink can be sent through the queue
+func TestOfflineQueue_AllowShrink(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+	server.setStatus(0)
+
+	shrinkStore := NewMemoryShrinkStore()
+	a.NoError(shrinkStore.SetLastCount(server.URL, 10))
+	client, err := NewClient(WithShrinkGuard(ShrinkGuard{MaxShrinkPercent: 50, Store: shrinkStore}))
+	a.NoError(err)
+	queue, err := NewOfflineQueue(client, t.TempDir(), QueueOptions{})
+	a.NoError(err)
+
+	// refused, so it's moved to the dead letter directory
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(2, true)))
+	a.NoError(queue.Drain(context.Background()))
+	a.Equal(1, queue.Stats().Dropped)
+
+	a.NoError(queue.EnqueueWithOptions(server.URL, buildTestBatch(2, true), EnqueueOptions{AllowShrink: true}))
+	a.NoError(queue.Drain(context.Background()))
+	stats := queue.Stats()
+	a.Equal(1, stats.Sent)
+	a.Len(server.received(), 1)
+}
+
+// Test that the queue sends the batch as it was enqueued
+func TestOfflineQueue_EnqueueCopies(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+	server.setStatus(0)
+
+	client, err := NewClient()
+	a.NoError(err)
+	queue, err := NewOfflineQueue(client, t.TempDir(), QueueOptions{})
+	a.NoError(err)
+
+	batch := buildTestBatch(1, false)
+	a.NoError(queue.Enqueue(server.URL, batch))
+	batch.Upserts[0].Value = "changed"
+	a.NoError(queue.Drain(context.Background()))
+	a.Equal("test000", server.received()[0].Upserts[0].Value)
+}
+
+// Test that a batch superseded while it's being sent keeps its checkpoint until the send is done, and
+// doesn't leave it behind after
+func TestOfflineQueue_SupersededWhileSending(t *testing.T) {
+	a := require.New(t)
+
+	// the first part is held until the newer batch is enqueued, then every other request fails
+	started := make(chan struct{})
+	release := make(chan struct{})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		close(started)
+		<-release
+		_, _ = w.Write([]byte(`{"guid":"abc"}`))
+	}))
+	defer server.Close()
+
+	store, err := NewFileCheckpointStore(t.TempDir())
+	a.NoError(err)
+	client, err := NewClient(WithMaxPartSize(200), WithCompression(false), WithCheckpointStore(store))
+	a.NoError(err)
+	dir := t.TempDir()
+	queue, err := NewOfflineQueue(client, dir, QueueOptions{})
+	a.NoError(err)
+
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(10, true)))
+	done := make(chan error)
+	go func() {
+		done <- queue.Drain(context.Background())
+	}()
+
+	<-started
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(2, true)))
+	close(release)
+	a.Error(<-done)
+
+	// the superseded batch checkpointed its first part, then was cleaned up once its send failed
+	_, err = store.Load(filepath.Join(dir, fmt.Sprintf("%020d.json", 1)))
+	a.True(errors.Is(err, ErrNotFound))
+	_, err = os.Stat(filepath.Join(dir, fmt.Sprintf("%020d.json", 1)))
+	a.True(os.IsNotExist(err))
+
+	stats := queue.Stats()
+	a.Equal(1, stats.Depth)
+	a.Equal(1, stats.Superseded)
+}
+
+func TestOfflineQueue_Run(t *testing.T) {
+	a := require.New(t)
+
+	server := newQueueServer(a)
+	defer server.Close()
+
+	client, err := NewClient()
+	a.NoError(err)
+	queue, err := NewOfflineQueue(client, t.TempDir(), QueueOptions{RetryInterval: 10 * time.Millisecond})
+	a.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- queue.Run(ctx)
+	}()
+
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(1, false)))
+	time.Sleep(50 * time.Millisecond)
+	a.Equal(1, queue.Stats().Depth)
+
+	// retried once the API's back
+	server.setStatus(0)
+	a.Eventually(func() bool {
+		return queue.Stats().Sent == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// and picks up new batches
+	a.NoError(queue.Enqueue(server.URL, buildTestBatch(1, false)))
+	a.Eventually(func() bool {
+		return queue.Stats().Sent == 2
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	a.True(errors.Is(<-done, context.Canceled))
+}
@@ -0,0 +1,164 @@
+package hippo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_REQUESTS_PER_SECOND = 1 // NewHippo's pace, which matches the pause we used to take between batch parts
+)
+
+// RateLimiter paces the requests made through Client.Do
+type RateLimiter interface {
+	// Wait blocks until a request with a body of the given size may be sent, or until the context is done
+	Wait(ctx context.Context, bytes int) error
+}
+
+// limiterRefunder is a RateLimiter that can take back a request it let through, which won't be sent after all
+type limiterRefunder interface {
+	refund(bytes int)
+}
+
+// Clock abstracts time for rate limiting, so tests can run without real sleeps
+type Clock interface {
+	Now() time.Time
+
+	// Sleep sleeps for the given duration, returning early with an error if the context is done
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	return sleepContext(ctx, d)
+}
+
+// tokenBucket is a token bucket that may go into debt - callers wait until the debt is paid off
+type tokenBucket struct {
+	rate    float64 // tokens per second - zero means unlimited
+	burst   float64
+	tokens  float64
+	updated time.Time
+}
+
+// take takes n tokens, returning how long the caller needs to wait for them
+func (b *tokenBucket) take(now time.Time, n float64) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.updated = now
+
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// give returns tokens that were taken, but not used
+func (b *tokenBucket) give(n float64) {
+	if b.rate <= 0 {
+		return
+	}
+
+	b.tokens += n
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// TokenBucketLimiter limits requests per second and bytes per second with token buckets.
+// It's safe to share between goroutines, and between Clients.
+type TokenBucketLimiter struct {
+	lock     sync.Mutex
+	clock    Clock
+	requests tokenBucket
+	bytes    tokenBucket
+}
+
+// NewTokenBucketLimiter builds a new TokenBucketLimiter
+// - a rate of zero doesn't limit that dimension
+// - burst is how many requests or bytes can go out at once after a quiet period, and is raised to 1 if lower
+func NewTokenBucketLimiter(requestsPerSecond float64, requestBurst int, bytesPerSecond float64, byteBurst int) *TokenBucketLimiter {
+	if requestBurst < 1 {
+		requestBurst = 1
+	}
+	if byteBurst < 1 {
+		byteBurst = 1
+	}
+
+	return &TokenBucketLimiter{
+		clock: realClock{},
+		requests: tokenBucket{
+			rate:   requestsPerSecond,
+			burst:  float64(requestBurst),
+			tokens: float64(requestBurst),
+		},
+		bytes: tokenBucket{
+			rate:   bytesPerSecond,
+			burst:  float64(byteBurst),
+			tokens: float64(byteBurst),
+		},
+	}
+}
+
+// SetClock sets the clock used to measure time and sleep - only needed for testing
+func (l *TokenBucketLimiter) SetClock(clock Clock) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.clock = clock
+	now := clock.Now()
+	l.requests.updated = now
+	l.bytes.updated = now
+}
+
+// Wait blocks until a request with a body of the given size may be sent, or until the context is done.
+// Tokens are reserved up front, so concurrent callers queue up behind each other rather than all waking at once.
+func (l *TokenBucketLimiter) Wait(ctx context.Context, bytes int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	clock := l.clock
+	now := clock.Now()
+	wait := l.requests.take(now, 1)
+	if bytesWait := l.bytes.take(now, float64(bytes)); bytesWait > wait {
+		wait = bytesWait
+	}
+	l.lock.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	if err := clock.Sleep(ctx, wait); err != nil {
+		// we won't be sending - hand the reservation back
+		l.refund(bytes)
+		return err
+	}
+	return nil
+}
+
+// refund hands back the tokens for a request that won't be sent
+func (l *TokenBucketLimiter) refund(bytes int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.requests.give(1)
+	l.bytes.give(float64(bytes))
+}
@@ -0,0 +1,127 @@
+package hippo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock only moves forward when something sleeps on it
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTokenBucketLimiter_Requests(t *testing.T) {
+	a := require.New(t)
+
+	clock := newFakeClock()
+	sut := NewTokenBucketLimiter(2, 2, 0, 0)
+	sut.SetClock(clock)
+
+	// burst of 2 goes right through, then we're paced at 2 per second
+	for i := 0; i < 5; i++ {
+		a.NoError(sut.Wait(context.Background(), 1000000))
+	}
+	a.Equal([]time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}, clock.sleeps)
+
+	// after a quiet period, the burst is available again - but no more than that
+	clock.sleeps = nil
+	clock.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		a.NoError(sut.Wait(context.Background(), 0))
+	}
+	a.Equal([]time.Duration{500 * time.Millisecond}, clock.sleeps)
+}
+
+func TestTokenBucketLimiter_Bytes(t *testing.T) {
+	a := require.New(t)
+
+	clock := newFakeClock()
+	sut := NewTokenBucketLimiter(0, 0, 1000, 1000)
+	sut.SetClock(clock)
+
+	a.NoError(sut.Wait(context.Background(), 1000))
+	a.Empty(clock.sleeps)
+
+	// requests bigger than the burst wait until the debt is paid off
+	a.NoError(sut.Wait(context.Background(), 2500))
+	a.NoError(sut.Wait(context.Background(), 500))
+	a.Equal([]time.Duration{2500 * time.Millisecond, 500 * time.Millisecond}, clock.sleeps)
+}
+
+func TestTokenBucketLimiter_Cancelled(t *testing.T) {
+	a := require.New(t)
+
+	sut := NewTokenBucketLimiter(1, 1, 0, 0)
+	a.NoError(sut.Wait(context.Background(), 0))
+
+	// the next token is a second away - cancel before then
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	a.Equal(context.DeadlineExceeded, sut.Wait(ctx, 0))
+	a.True(time.Since(start) < 500*time.Millisecond)
+
+	// already-cancelled contexts don't take a token
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.Equal(context.Canceled, sut.Wait(cancelled, 0))
+}
+
+// Test that the limiter paces requests made by the Client, without really sleeping
+func TestClient_RateLimiter(t *testing.T) {
+	a := require.New(t)
+
+	clock := newFakeClock()
+	limiter := NewTokenBucketLimiter(1, 1, 0, 0)
+	limiter.SetClock(clock)
+
+	// EnsureDimensions goes through the limiter too
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"customDimensions":[]}`))
+	}))
+	defer server.Close()
+
+	sut := NewHippo("agent", "email", "token")
+	sut.SetRateLimiter(limiter)
+
+	_, err := sut.EnsureDimensions(context.Background(), server.URL, map[string]string{"c_one": "One", "c_two": "Two"})
+	a.NoError(err)
+	a.Equal([]time.Duration{time.Second, time.Second}, clock.sleeps)
+}
@@ -0,0 +1,147 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how batch parts are retried after transient failures.
+// The zero value disables retries.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a part is re-sent after the first attempt fails
+	MaxRetries int
+
+	// InitialBackoff is how long to wait before the first retry
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the backoff between retries - a server's Retry-After is honored even if it's longer
+	MaxBackoff time.Duration
+
+	// Multiplier grows the backoff after each retry - values below 1 are treated as 1
+	Multiplier float64
+
+	// Jitter randomizes each backoff by up to this fraction of it (0-1), to spread out retries from many senders
+	Jitter float64
+
+	// RetryUnknownFirstPart re-sends the first part when its response was lost, and we can't tell whether the server
+	// received it. Since the batch GUID comes back in that response, a re-sent first part starts a new batch on the
+	// server, leaving the original one incomplete. Later parts always carry the GUID, so they're safe to re-send.
+	RetryUnknownFirstPart bool
+}
+
+// DefaultRetryPolicy returns a reasonable policy for retrying batch parts
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// backoff returns how long to wait before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		// spread evenly over [backoff - jitter, backoff + jitter]
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		backoff += backoff * jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if d := when.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryDecision determines whether a failed part may be re-sent, and the minimum time to wait before doing so
+// - firstPart is whether this is the part that we're expecting the batch GUID back from
+func (p RetryPolicy) retryDecision(err error, firstPart bool) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusInternalServerError:
+			// the server answered, so we know the part wasn't accepted - safe to re-send, even the first part
+			return true, apiErr.RetryAfter
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			// a proxy answered - the server behind it may or may not have received the part
+			return !firstPart || p.RetryUnknownFirstPart, 0
+		}
+		return false, 0
+	}
+
+	// couldn't connect at all, or the circuit breaker didn't let us try - the server never saw the part
+	if isConnectError(err) || errors.Is(err, ErrCircuitOpen) {
+		return true, 0
+	}
+
+	// the part may or may not have been received
+	if firstPart {
+		return p.RetryUnknownFirstPart, 0
+	}
+	return true, 0
+}
+
+// sleepContext sleeps for the given duration, returning early with an error if the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isConnectError returns whether the error means we couldn't connect to the server at all, so it never saw the request
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
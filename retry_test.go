@@ -0,0 +1,88 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	a := require.New(t)
+
+	sut := RetryPolicy{
+		MaxRetries:     5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     3,
+	}
+	a.Equal(100*time.Millisecond, sut.backoff(1))
+	a.Equal(300*time.Millisecond, sut.backoff(2))
+	a.Equal(900*time.Millisecond, sut.backoff(3))
+	a.Equal(time.Second, sut.backoff(4))
+	a.Equal(time.Second, sut.backoff(5))
+
+	// jitter stays within bounds
+	sut.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := sut.backoff(2)
+		a.True(backoff >= 150*time.Millisecond, backoff)
+		a.True(backoff <= 450*time.Millisecond, backoff)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	a := require.New(t)
+
+	now := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	a.Equal(time.Duration(0), parseRetryAfter("", now))
+	a.Equal(time.Duration(0), parseRetryAfter("garbage", now))
+	a.Equal(time.Duration(0), parseRetryAfter("-5", now))
+	a.Equal(7*time.Second, parseRetryAfter("7", now))
+	a.Equal(90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	a.Equal(time.Duration(0), parseRetryAfter(now.Add(-90*time.Second).Format(http.TimeFormat), now))
+}
+
+func TestRetryPolicy_RetryDecision(t *testing.T) {
+	a := require.New(t)
+
+	sut := DefaultRetryPolicy()
+
+	tests := []struct {
+		err       error
+		firstPart bool
+		retry     bool
+		minWait   time.Duration
+	}{
+		{context.Canceled, false, false, 0},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), false, false, 0},
+		{&APIError{StatusCode: 429, RetryAfter: 3 * time.Second}, true, true, 3 * time.Second},
+		{&APIError{StatusCode: 503}, true, true, 0},
+		{&APIError{StatusCode: 500}, true, true, 0},
+		{&APIError{StatusCode: 502}, true, false, 0},
+		{&APIError{StatusCode: 504}, false, true, 0},
+		{&APIError{StatusCode: 400}, false, false, 0},
+		{&APIError{StatusCode: 401}, false, false, 0},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true, true, 0},
+		{&net.DNSError{Err: "no such host"}, true, true, 0},
+		{&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true, false, 0},
+		{&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, false, true, 0},
+	}
+	for i, test := range tests {
+		retry, minWait := sut.retryDecision(test.err, test.firstPart)
+		a.Equal(test.retry, retry, "test %d", i)
+		a.Equal(test.minWait, minWait, "test %d", i)
+	}
+
+	// lost first-part responses are only retried when allowed
+	sut.RetryUnknownFirstPart = true
+	retry, _ := sut.retryDecision(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true)
+	a.True(retry)
+	retry, _ = sut.retryDecision(&APIError{StatusCode: 502}, true)
+	a.True(retry)
+}
@@ -0,0 +1,215 @@
+package hippo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// ShrinkGuard refuses replace-all batches that would shrink what's at a URL by too much, such as when an
+// upstream source comes back empty. It remembers how many upserts the last successful replace-all batch to
+// each URL had, and compares new replace-all batches against that. Pass AllowShrink to send one anyway.
+// - URLs without a recorded count are never refused
+// - incremental batches are never checked, and don't change the recorded count
+type ShrinkGuard struct {
+	MaxShrinkPercent float64     // refuse batches with this much fewer upserts, as a percentage - zero doesn't check
+	MaxShrinkCount   int         // refuse batches with this many fewer upserts - zero doesn't check
+	Store            ShrinkStore // optional - nil keeps counts in memory
+}
+
+// ShrinkStore keeps the upsert count of the last successful replace-all batch to each URL
+type ShrinkStore interface {
+	// LastCount returns the count recorded for the URL, and whether there is one
+	LastCount(url string) (int, bool, error)
+
+	// SetLastCount records the count for the URL
+	SetLastCount(url string, count int) error
+}
+
+// ShrinkError is returned when a ShrinkGuard refuses a replace-all batch
+type ShrinkError struct {
+	URL       string
+	LastCount int // upserts in the last successful replace-all batch
+	Count     int // upserts in the refused batch
+}
+
+func (e *ShrinkError) Error() string {
+	return fmt.Sprintf("Refusing replace-all batch to %s: %d upserts, down from %d - pass AllowShrink if this is intended", e.URL, e.Count, e.LastCount)
+}
+
+// Is reports whether the error belongs to one of the error classes - it's a validation error, since sending it
+// again won't help
+func (e *ShrinkError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// SetShrinkGuard sets the guard that replace-all batches are checked against - nil doesn't check them
+func (c *Client) SetShrinkGuard(guard *ShrinkGuard) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.shrinkGuard = newShrinkGuard(guard)
+}
+
+// WithShrinkGuard sets the guard that replace-all batches are checked against - see ShrinkGuard
+func WithShrinkGuard(guard ShrinkGuard) Option {
+	return func(cfg *clientConfig) error {
+		cfg.client.shrinkGuard = newShrinkGuard(&guard)
+		return nil
+	}
+}
+
+// newShrinkGuard copies the guard, filling in a memory store if it doesn't have one
+func newShrinkGuard(guard *ShrinkGuard) *ShrinkGuard {
+	if guard == nil {
+		return nil
+	}
+	ret := *guard
+	if ret.Store == nil {
+		ret.Store = NewMemoryShrinkStore()
+	}
+	return &ret
+}
+
+// check returns a *ShrinkError if a replace-all batch with the given upsert count shrinks too much
+func (g *ShrinkGuard) check(url string, count int) error {
+	lastCount, ok, err := g.Store.LastCount(url)
+	if err != nil {
+		return fmt.Errorf("Error looking up last replace-all count: %w", err)
+	}
+	if !ok || count >= lastCount {
+		return nil
+	}
+
+	shrink := lastCount - count
+	if (g.MaxShrinkCount > 0 && shrink > g.MaxShrinkCount) ||
+		(g.MaxShrinkPercent > 0 && float64(shrink)*100/float64(lastCount) > g.MaxShrinkPercent) {
+		return &ShrinkError{URL: url, LastCount: lastCount, Count: count}
+	}
+	return nil
+}
+
+// guardShrink checks a replace-all batch against the Client's ShrinkGuard just before its complete part is
+// built, once its upsert count is known, and records the count once the batch has been sent
+func (s *batchSender) guardShrink(replaceAll bool, allowShrink bool, count func() int) {
+	s.client.lock.RLock()
+	guard := s.client.shrinkGuard
+	s.client.lock.RUnlock()
+
+	// kept even without a guard, so a checkpointed batch is resumed the same way
+	s.allowShrink = allowShrink
+	if guard == nil || !replaceAll {
+		return
+	}
+	s.shrinkGuard = guard
+	s.shrinkCount = count
+}
+
+// checkShrink is called before the complete part is sent
+func (s *batchSender) checkShrink() error {
+	if s.shrinkGuard == nil || s.allowShrink {
+		return nil
+	}
+	return s.shrinkGuard.check(s.url, s.shrinkCount())
+}
+
+// recordShrinkCount is called once the batch has been sent
+func (s *batchSender) recordShrinkCount() {
+	if s.shrinkGuard == nil {
+		return
+	}
+	if err := s.shrinkGuard.Store.SetLastCount(s.url, s.shrinkCount()); err != nil {
+		s.log.Warn("error recording replace-all count", "url", s.url, "error", err)
+	}
+}
+
+// MemoryShrinkStore keeps replace-all counts in memory
+type MemoryShrinkStore struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+// NewMemoryShrinkStore builds a MemoryShrinkStore
+func NewMemoryShrinkStore() *MemoryShrinkStore {
+	return &MemoryShrinkStore{counts: make(map[string]int)}
+}
+
+// LastCount returns the count recorded for the URL
+func (s *MemoryShrinkStore) LastCount(url string) (int, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	count, ok := s.counts[url]
+	return count, ok, nil
+}
+
+// SetLastCount records the count for the URL
+func (s *MemoryShrinkStore) SetLastCount(url string, count int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.counts[url] = count
+	return nil
+}
+
+// FileShrinkStore keeps replace-all counts in a JSON file, so they survive restarts
+type FileShrinkStore struct {
+	lock sync.Mutex
+	path string
+}
+
+// NewFileShrinkStore builds a FileShrinkStore - the file is created when the first count is recorded
+func NewFileShrinkStore(path string) *FileShrinkStore {
+	return &FileShrinkStore{path: path}
+}
+
+// LastCount returns the count recorded for the URL
+func (s *FileShrinkStore) LastCount(url string) (int, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	counts, err := s.read()
+	if err != nil {
+		return 0, false, err
+	}
+	count, ok := counts[url]
+	return count, ok, nil
+}
+
+// SetLastCount records the count for the URL, rewriting the file
+func (s *FileShrinkStore) SetLastCount(url string, count int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	counts, err := s.read()
+	if err != nil {
+		return err
+	}
+	counts[url] = count
+
+	countsBytes, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error marshalling replace-all counts: %s", err)
+	}
+	if err := writeFileAtomic(s.path, countsBytes); err != nil {
+		return fmt.Errorf("Error writing replace-all counts: %s", err)
+	}
+	return nil
+}
+
+func (s *FileShrinkStore) read() (map[string]int, error) {
+	counts := make(map[string]int)
+	countsBytes, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return counts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading replace-all counts: %s", err)
+	}
+	if err := json.Unmarshal(countsBytes, &counts); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling replace-all counts: %s", err)
+	}
+	return counts, nil
+}
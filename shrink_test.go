@@ -0,0 +1,175 @@
+package hippo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShrinkGuard_Percent(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	client, err := NewClient(WithShrinkGuard(ShrinkGuard{MaxShrinkPercent: 50}))
+	a.NoError(err)
+	ctx := context.Background()
+
+	// nothing to compare the first batch to
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(10, true))
+	a.NoError(err)
+	a.Len(server.receivedParts(), 1)
+
+	// losing more than half is refused, before anything's sent
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(4, true))
+	var shrinkErr *ShrinkError
+	a.True(errors.As(err, &shrinkErr))
+	a.Equal(ShrinkError{URL: server.URL, LastCount: 10, Count: 4}, *shrinkErr)
+	a.True(errors.Is(err, ErrValidation))
+	a.Len(server.receivedParts(), 1)
+
+	// and so is an empty batch
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(0, true))
+	a.True(errors.As(err, &shrinkErr))
+	a.Len(server.receivedParts(), 1)
+
+	// unless it's allowed
+	_, err = client.SendBatchWithOptions(ctx, server.URL, buildTestBatch(4, true), SendBatchOptions{AllowShrink: true})
+	a.NoError(err)
+	a.Len(server.receivedParts(), 2)
+
+	// which resets the count to compare to
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(3, true))
+	a.NoError(err)
+
+	// incremental batches aren't checked, and don't change the count
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(1, false))
+	a.NoError(err)
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(1, true))
+	a.True(errors.As(err, &shrinkErr))
+	a.Equal(3, shrinkErr.LastCount)
+
+	// other URLs are counted separately
+	_, err = client.SendBatch(ctx, server.URL+"/other", buildTestBatch(1, true))
+	a.NoError(err)
+}
+
+func TestShrinkGuard_Count(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	client, err := NewClient()
+	a.NoError(err)
+	client.SetShrinkGuard(&ShrinkGuard{MaxShrinkCount: 5})
+	ctx := context.Background()
+
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(100, true))
+	a.NoError(err)
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(95, true))
+	a.NoError(err)
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(89, true))
+	a.True(errors.Is(err, ErrValidation))
+
+	// no guard, no checks
+	client.SetShrinkGuard(nil)
+	_, err = client.SendBatch(ctx, server.URL, buildTestBatch(0, true))
+	a.NoError(err)
+}
+
+func TestShrinkGuard_Stream(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	store := NewMemoryShrinkStore()
+	a.NoError(store.SetLastCount(server.URL, 50))
+	client, err := NewClient(WithMaxPartSize(300), WithShrinkGuard(ShrinkGuard{MaxShrinkPercent: 10, Store: store}))
+	a.NoError(err)
+
+	// the count isn't known until the stream's read, so the final part is held back
+	opts := StreamOptions{ReplaceAll: true}
+	result, err := client.SendBatchStream(context.Background(), server.URL, opts, sliceStream(buildTestBatch(10, true)))
+	var shrinkErr *ShrinkError
+	a.True(errors.As(err, &shrinkErr))
+	a.Equal(10, shrinkErr.Count)
+	var sendErr *BatchSendError
+	a.True(errors.As(err, &sendErr))
+	a.True(result.PartsSent > 0)
+	for _, part := range server.receivedParts() {
+		a.False(part.IsComplete)
+	}
+
+	opts.AllowShrink = true
+	_, err = client.SendBatchStream(context.Background(), server.URL, opts, sliceStream(buildTestBatch(10, true)))
+	a.NoError(err)
+	count, ok, err := store.LastCount(server.URL)
+	a.NoError(err)
+	a.True(ok)
+	a.Equal(10, count)
+}
+
+func TestFileShrinkStore(t *testing.T) {
+	a := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "counts.json")
+	store := NewFileShrinkStore(path)
+
+	_, ok, err := store.LastCount("https://api.kentik.com/a")
+	a.NoError(err)
+	a.False(ok)
+
+	a.NoError(store.SetLastCount("https://api.kentik.com/a", 10))
+	a.NoError(store.SetLastCount("https://api.kentik.com/b", 20))
+
+	// counts survive a restart
+	store = NewFileShrinkStore(path)
+	count, ok, err := store.LastCount("https://api.kentik.com/a")
+	a.NoError(err)
+	a.True(ok)
+	a.Equal(10, count)
+	count, _, err = store.LastCount("https://api.kentik.com/b")
+	a.NoError(err)
+	a.Equal(20, count)
+}
+
+// Test that a resumed replace-all batch is checked, and its count recorded, like any other
+func TestShrinkGuard_ResumeBatch(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	shrinkStore := NewMemoryShrinkStore()
+	a.NoError(shrinkStore.SetLastCount(server.URL, 10))
+	client, err := NewClient(WithShrinkGuard(ShrinkGuard{MaxShrinkPercent: 50, Store: shrinkStore}))
+	a.NoError(err)
+
+	// a batch of 4 upserts, after its first part
+	checkpoint := &Checkpoint{ID: "c_test", URL: server.URL, BatchGUID: "c8285742-f7a4-4870-933d-665b15c31eda", ReplaceAll: true, PartsSent: 1, UpsertsTotal: 4}
+	for _, upsert := range buildTestBatch(4, true).Upserts {
+		upsertBytes, err := json.Marshal(&upsert)
+		a.NoError(err)
+		checkpoint.Upserts = append(checkpoint.Upserts, upsertBytes)
+	}
+
+	_, err = client.ResumeBatch(context.Background(), checkpoint)
+	var shrinkErr *ShrinkError
+	a.True(errors.As(err, &shrinkErr))
+	a.Empty(server.receivedParts())
+
+	checkpoint.AllowShrink = true
+	_, err = client.ResumeBatch(context.Background(), checkpoint)
+	a.NoError(err)
+	count, ok, err := shrinkStore.LastCount(server.URL)
+	a.NoError(err)
+	a.True(ok)
+	a.Equal(4, count)
+}
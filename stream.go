@@ -0,0 +1,233 @@
+package hippo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// StreamOptions describes a batch sent with SendBatchStream
+type StreamOptions struct {
+	ReplaceAll  bool
+	TTLMinutes  uint32
+	Progress    ProgressFunc // optional - called as each part is accepted
+	AllowShrink bool         // sends a replace-all batch even if the Client's ShrinkGuard would refuse it
+}
+
+// SendBatchStream sends a batch whose upserts come from the next function, serializing and sending parts
+// as the upserts arrive, rather than holding the whole batch in memory.
+//   - next returns io.EOF once there are no more upserts; any other error aborts the batch
+//   - unlike SendBatch, upserts with the same value aren't combined - group criteria by value before streaming them
+//   - since the upsert count isn't known up front, the result's UpsertsTotal counts the upserts read so far
+//   - a replace-all batch isn't applied until its final part is sent, so an aborted stream leaves the
+//     existing values alone
+//   - the Client's ShrinkGuard checks a replace-all batch once it's been read, before its final part is sent
+func (c *Client) SendBatchStream(ctx context.Context, url string, opts StreamOptions, next func() (*TagUpsert, error)) (*SendBatchResult, error) {
+	c.lock.RLock()
+	sender := c.sender
+	c.lock.RUnlock()
+
+	builder := newStreamBuilder(c.OutgoingRequestSize, opts.ReplaceAll, opts.TTLMinutes, next)
+	builder.sender = sender
+
+	ret := &SendBatchResult{}
+	s := c.newBatchSender(url, ret)
+	s.progress = opts.Progress
+	s.guardShrink(opts.ReplaceAll, opts.AllowShrink, func() int { return builder.upsertsRead })
+	builder.onRead = func() {
+		// progress callbacks see the result while parts are still being read
+		s.lock.Lock()
+		ret.UpsertsTotal++
+		s.lock.Unlock()
+	}
+	err := s.run(ctx, builder)
+	return ret, err
+}
+
+// SendBatchChan works like SendBatchStream, reading upserts from a channel until it's closed
+func (c *Client) SendBatchChan(ctx context.Context, url string, opts StreamOptions, upserts <-chan *TagUpsert) (*SendBatchResult, error) {
+	return c.SendBatchStream(ctx, url, opts, func() (*TagUpsert, error) {
+		select {
+		case upsert, ok := <-upserts:
+			if !ok {
+				return nil, io.EOF
+			}
+			return upsert, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
+
+// streamBuilder builds batch parts from upserts as they're read, holding at most one part's worth at a time.
+// It builds the same JSON as BatchBuilder.
+type streamBuilder struct {
+	desiredSize int
+	buf         *bytes.Buffer
+	next        func() (*TagUpsert, error)
+	pending     []byte // serialized upsert that didn't fit into the last part
+	batchGUID   string
+	replaceAll  bool
+	ttlMinutes  uint32
+	sender      TagBatchPartSender
+	builtCount  int
+	upsertsRead int
+	onRead      func() // optional - called as each upsert is read
+	exhausted   bool   // next has returned io.EOF
+	complete    bool   // built the part that completes the batch
+
+	compressionRatio float64  // expected compressed/JSON ratio - zero sizes parts by their JSON
+	lastUpserts      [][]byte // upserts in the last part built
+}
+
+func newStreamBuilder(desiredSize int, replaceAll bool, ttlMinutes uint32, next func() (*TagUpsert, error)) *streamBuilder {
+	return &streamBuilder{
+		desiredSize: desiredSize,
+		buf:         bytes.NewBuffer(make([]byte, 0, desiredSize)),
+		next:        next,
+		replaceAll:  replaceAll,
+		ttlMinutes:  ttlMinutes,
+	}
+}
+
+// SetBatchGUID sets the GUID that was returned after submitting the first part to the server.
+func (b *streamBuilder) SetBatchGUID(guid string) {
+	b.batchGUID = guid
+}
+
+// IsComplete returns whether the last part built completed the batch
+func (b *streamBuilder) IsComplete() bool {
+	return b.complete
+}
+
+// nextSerializedUpsert returns the next serialized upsert, or nil once there are no more
+func (b *streamBuilder) nextSerializedUpsert() ([]byte, error) {
+	if b.pending != nil {
+		ser := b.pending
+		b.pending = nil
+		return ser, nil
+	}
+	if b.exhausted {
+		return nil, nil
+	}
+
+	upsert, err := b.next()
+	if err == io.EOF {
+		b.exhausted = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading upsert: %w", err)
+	}
+	if upsert == nil {
+		return nil, fmt.Errorf("Error reading upsert: upsert cannot be nil")
+	}
+	b.upsertsRead++
+	if b.onRead != nil {
+		b.onRead()
+	}
+
+	ser, err := json.Marshal(upsert)
+	if err != nil {
+		return nil, fmt.Errorf("Error serializing TagUpsert: %s", err)
+	}
+	return ser, nil
+}
+
+// BuildBatchRequestCounts builds and returns the next serialized batch part, reading upserts until it's full.
+// - returns serialized batch, upsert count, and delete count (always zero)
+// - like BatchBuilder, a part always gets at least one upsert, even if that upsert is too big
+func (b *streamBuilder) BuildBatchRequestCounts() ([]byte, int, int, error) {
+	if b.complete {
+		// nothing to do
+		return nil, 0, 0, nil
+	}
+
+	if b.batchGUID == "" && b.builtCount > 0 {
+		return nil, 0, 0, fmt.Errorf("Only first batch may be sent without batch GUID")
+	}
+
+	b.buf.Reset()
+	b.lastUpserts = make([][]byte, 0)
+	if err := writeBatchPartHeader(b.buf, b.batchGUID, b.replaceAll, b.ttlMinutes, b.sender); err != nil {
+		return nil, 0, 0, err
+	}
+
+	// leave some space for batch scaffolding and `"complete":false`
+	availableSpace := compressedTargetSize(b.desiredSize, b.compressionRatio) - b.buf.Len() - 40
+
+	upsertCount := 0
+	for {
+		ser, err := b.nextSerializedUpsert()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if ser == nil {
+			break
+		}
+		if upsertCount > 0 && len(ser)+1 > availableSpace {
+			// save it for the next part
+			b.pending = ser
+			break
+		}
+
+		if upsertCount > 0 {
+			if _, err := b.buf.WriteString(","); err != nil {
+				return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+			}
+			availableSpace--
+		}
+		if _, err := b.buf.Write(ser); err != nil {
+			return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+		}
+		availableSpace -= len(ser)
+		b.lastUpserts = append(b.lastUpserts, ser)
+		upsertCount++
+	}
+
+	b.complete = b.exhausted && b.pending == nil
+	if upsertCount == 0 && b.builtCount == 0 && !b.replaceAll {
+		// nothing was streamed, and there's nothing to replace
+		return nil, 0, 0, nil
+	}
+
+	if _, err := b.buf.WriteString(`],"complete":`); err != nil {
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if _, err := b.buf.WriteString(boolString(b.complete)); err != nil {
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+	if _, err := b.buf.WriteString(`}`); err != nil {
+		return nil, 0, 0, fmt.Errorf("Error writing string to buffer: %s", err)
+	}
+
+	b.builtCount++
+	return b.buf.Bytes(), upsertCount, 0, nil
+}
+
+// lastPartItems returns the serialized upserts in the last part built - streamed batches have no deletes
+func (b *streamBuilder) lastPartItems() ([][]byte, [][]byte) {
+	return b.lastUpserts, nil
+}
+
+// partHeader returns the fields that are the same in every part of the batch
+func (b *streamBuilder) partHeader() partHeader {
+	return partHeader{replaceAll: b.replaceAll, ttlMinutes: b.ttlMinutes, sender: b.sender}
+}
+
+// observeCompression updates the expected compression ratio, from how well the last part actually compressed.
+// Streamed upserts can't be sampled up front, so the first part is always sized by its JSON.
+func (b *streamBuilder) observeCompression(rawSize int, compressedSize int) {
+	if rawSize > 0 {
+		b.compressionRatio = float64(compressedSize) / float64(rawSize)
+	}
+}
+
+// lowerDesiredSize lowers the desired size of the parts still to be built - it's never raised
+func (b *streamBuilder) lowerDesiredSize(size int) {
+	if size < b.desiredSize {
+		b.desiredSize = size
+	}
+}
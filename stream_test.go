@@ -0,0 +1,168 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sliceStream streams the upserts of a batch, then io.EOF
+func sliceStream(batch *TagBatchPart) func() (*TagUpsert, error) {
+	i := 0
+	return func() (*TagUpsert, error) {
+		if i >= len(batch.Upserts) {
+			return nil, io.EOF
+		}
+		i++
+		return &batch.Upserts[i-1], nil
+	}
+}
+
+func TestStreamBuilder_MatchesBatchBuilder(t *testing.T) {
+	a := require.New(t)
+
+	sender := TagBatchPartSender{ServiceName: "service-name"}
+
+	// a single upsert serializes the same either way
+	batch := buildTestBatch(1, true)
+	batchBuilder := NewBatchBuilder(3000000, true, 17)
+	batchBuilder.SetSenderInfo(sender)
+	a.NoError(batchBuilder.AddUpsert(&batch.Upserts[0]))
+	expected, _, err := batchBuilder.BuildBatchRequest()
+	a.NoError(err)
+
+	sut := newStreamBuilder(3000000, true, 17, sliceStream(batch))
+	sut.sender = sender
+	actual, upsertCount, deleteCount, err := sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Equal(1, upsertCount)
+	a.Equal(0, deleteCount)
+	a.True(sut.IsComplete())
+	a.Equal(string(expected), string(actual))
+
+	// and then it's done
+	actual, _, _, err = sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Nil(actual)
+}
+
+func TestStreamBuilder_Empty(t *testing.T) {
+	a := require.New(t)
+
+	// nothing to send for an empty non-replace-all batch
+	sut := newStreamBuilder(3000000, false, 0, sliceStream(&TagBatchPart{}))
+	batchBytes, upsertCount, _, err := sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Nil(batchBytes)
+	a.Zero(upsertCount)
+
+	// an empty replace-all batch is sent once, to clear out the values
+	sut = newStreamBuilder(3000000, true, 0, sliceStream(&TagBatchPart{}))
+	batchBytes, _, _, err = sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Equal(`{"guid":"","replace_all":true,"ttl_minutes":0,"upserts":[],"complete":true}`, string(batchBytes))
+	batchBytes, _, _, err = sut.BuildBatchRequestCounts()
+	a.NoError(err)
+	a.Nil(batchBytes)
+}
+
+func TestSendBatchStream_Success(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	sut, err := NewClient(WithPartConcurrency(2), WithMaxPartSize(200))
+	a.NoError(err)
+
+	batch := buildTestBatch(25, true)
+	progressCalls := 0
+	progress := func(part PartResult, result SendBatchResult) {
+		// the total counts the upserts read so far, which is always at least what's been sent
+		progressCalls++
+		a.True(result.UpsertsTotal >= result.UpsertsSent, result.String())
+		a.True(result.UpsertsTotal > 0)
+	}
+	opts := StreamOptions{ReplaceAll: true, TTLMinutes: 5, Progress: progress}
+	response, err := sut.SendBatchStream(context.Background(), server.URL, opts, sliceStream(batch))
+	a.NoError(err)
+	a.Equal(response.PartsSent, progressCalls)
+	a.Equal(25, response.UpsertsSent)
+	a.Equal(25, response.UpsertsTotal)
+	a.Equal("c8285742-f7a4-4870-933d-665b15c31eda", response.BatchGUID)
+
+	parts := server.receivedParts()
+	a.Equal(response.PartsSent, len(parts))
+	a.True(len(parts) > 5)
+	seen := make(map[string]bool)
+	for i, part := range parts {
+		a.True(part.ReplaceAll)
+		a.Equal(uint32(5), part.TTLMinutes)
+		a.NotEmpty(part.Upserts)
+		a.Equal(i == len(parts)-1, part.IsComplete)
+		for _, upsert := range part.Upserts {
+			seen[upsert.Value] = true
+		}
+	}
+	a.Equal(25, len(seen))
+}
+
+func TestSendBatchChan_Success(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	sut, err := NewClient(WithMaxPartSize(200))
+	a.NoError(err)
+
+	upserts := make(chan *TagUpsert)
+	go func() {
+		defer close(upserts)
+		batch := buildTestBatch(10, false)
+		for i := range batch.Upserts {
+			upserts <- &batch.Upserts[i]
+		}
+	}()
+
+	response, err := sut.SendBatchChan(context.Background(), server.URL, StreamOptions{}, upserts)
+	a.NoError(err)
+	a.Equal(10, response.UpsertsSent)
+	a.Equal(10, response.UpsertsTotal)
+	a.True(response.PartsSent > 1)
+}
+
+// Test that an error from the stream aborts the batch without completing it
+func TestSendBatchStream_SourceError(t *testing.T) {
+	a := require.New(t)
+
+	server := newConcurrencyServer(a)
+	defer server.Close()
+
+	sut, err := NewClient(WithMaxPartSize(200))
+	a.NoError(err)
+
+	sourceErr := errors.New("export failed")
+	batch := buildTestBatch(10, true)
+	next := sliceStream(batch)
+	read := 0
+	response, err := sut.SendBatchStream(context.Background(), server.URL, StreamOptions{ReplaceAll: true}, func() (*TagUpsert, error) {
+		read++
+		if read > 6 {
+			return nil, sourceErr
+		}
+		return next()
+	})
+	a.Error(err)
+	a.True(errors.Is(err, sourceErr))
+	a.Equal(6, response.UpsertsTotal)
+	a.True(response.UpsertsSent < 6)
+
+	for _, part := range server.receivedParts() {
+		a.False(part.IsComplete, fmt.Sprintf("%+v", part))
+	}
+}
@@ -1187,17 +1187,74 @@ func (m *FlexUint64Range) GetEnd() uint64 {
 	return 0
 }
 
+// APIValueError is an error the server reported for a single value in a batch
+type APIValueError struct {
+	Value string `protobuf:"bytes,1,opt,name=Value,proto3" json:"value"`
+	Error string `protobuf:"bytes,2,opt,name=Error,proto3" json:"error"`
+}
+
+func (m *APIValueError) Reset()      { *m = APIValueError{} }
+func (*APIValueError) ProtoMessage() {}
+func (*APIValueError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6398c1b1d4769419, []int{11}
+}
+func (m *APIValueError) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *APIValueError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_APIValueError.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *APIValueError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_APIValueError.Merge(m, src)
+}
+func (m *APIValueError) XXX_Size() int {
+	return m.Size()
+}
+func (m *APIValueError) XXX_DiscardUnknown() {
+	xxx_messageInfo_APIValueError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_APIValueError proto.InternalMessageInfo
+
+func (m *APIValueError) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *APIValueError) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
 // APIServerResponse is the response from the server
 type APIServerResponse struct {
 	GUID    string `protobuf:"bytes,1,opt,name=GUID,proto3" json:"guid,omitempty"`
 	Message string `protobuf:"bytes,2,opt,name=Message,proto3" json:"message,omitempty"`
 	Error   string `protobuf:"bytes,3,opt,name=Error,proto3" json:"error,omitempty"`
+	// batch status fields - only set in responses to batch status requests
+	Status         string          `protobuf:"bytes,4,opt,name=Status,proto3" json:"status,omitempty"`
+	UpsertsApplied uint32          `protobuf:"varint,5,opt,name=UpsertsApplied,proto3" json:"upserts_applied,omitempty"`
+	DeletesApplied uint32          `protobuf:"varint,6,opt,name=DeletesApplied,proto3" json:"deletes_applied,omitempty"`
+	ValueErrors    []APIValueError `protobuf:"bytes,7,rep,name=ValueErrors,proto3" json:"value_errors,omitempty"`
 }
 
 func (m *APIServerResponse) Reset()      { *m = APIServerResponse{} }
 func (*APIServerResponse) ProtoMessage() {}
 func (*APIServerResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6398c1b1d4769419, []int{11}
+	return fileDescriptor_6398c1b1d4769419, []int{12}
 }
 func (m *APIServerResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1247,6 +1304,34 @@ func (m *APIServerResponse) GetError() string {
 	return ""
 }
 
+func (m *APIServerResponse) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *APIServerResponse) GetUpsertsApplied() uint32 {
+	if m != nil {
+		return m.UpsertsApplied
+	}
+	return 0
+}
+
+func (m *APIServerResponse) GetDeletesApplied() uint32 {
+	if m != nil {
+		return m.DeletesApplied
+	}
+	return 0
+}
+
+func (m *APIServerResponse) GetValueErrors() []APIValueError {
+	if m != nil {
+		return m.ValueErrors
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*TagBatchPartSender)(nil), "hippo.TagBatchPartSender")
 	proto.RegisterType((*TagBatchPart)(nil), "hippo.TagBatchPart")
@@ -1259,125 +1344,134 @@ func init() {
 	proto.RegisterType((*PortRange)(nil), "hippo.PortRange")
 	proto.RegisterType((*FlexUint32Range)(nil), "hippo.FlexUint32Range")
 	proto.RegisterType((*FlexUint64Range)(nil), "hippo.FlexUint64Range")
+	proto.RegisterType((*APIValueError)(nil), "hippo.APIValueError")
 	proto.RegisterType((*APIServerResponse)(nil), "hippo.APIServerResponse")
 }
 
 func init() { proto.RegisterFile("tagging.proto", fileDescriptor_6398c1b1d4769419) }
 
 var fileDescriptor_6398c1b1d4769419 = []byte{
-	// 1787 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x98, 0x4f, 0x73, 0xdb, 0xb8,
-	0x19, 0xc6, 0xad, 0xf8, 0x9f, 0x04, 0xdb, 0x91, 0x8d, 0x38, 0x09, 0x92, 0xdd, 0x15, 0x5d, 0x6d,
-	0x9b, 0x7a, 0xbb, 0x59, 0x5b, 0x00, 0x29, 0xd9, 0x6e, 0xd2, 0x76, 0x25, 0xd9, 0x71, 0xd4, 0x49,
-	0x32, 0x1a, 0xca, 0xd9, 0x99, 0xf6, 0xa2, 0x61, 0x24, 0x2c, 0xcd, 0x59, 0x89, 0xe4, 0x90, 0x50,
-	0x26, 0xbe, 0xf5, 0xda, 0x5b, 0x6f, 0xfd, 0x0a, 0xed, 0x37, 0xd9, 0x63, 0x7a, 0xdb, 0x13, 0xa7,
-	0x71, 0x2e, 0x1d, 0x9e, 0xf2, 0x11, 0x3a, 0x04, 0x09, 0x91, 0x02, 0x3d, 0xd3, 0x72, 0x2f, 0x1e,
-	0xeb, 0xc5, 0xf3, 0xfc, 0x08, 0x51, 0x0f, 0xf1, 0x82, 0x00, 0x5b, 0xcc, 0x30, 0x4d, 0xcb, 0x36,
-	0x0f, 0x5c, 0xcf, 0x61, 0x0e, 0x5c, 0xbd, 0xb4, 0x5c, 0xd7, 0x79, 0xb8, 0x6b, 0x3a, 0xa6, 0xc3,
-	0x2b, 0x87, 0xd1, 0x7f, 0xf1, 0x60, 0xfd, 0x5f, 0x25, 0x00, 0x2f, 0x0c, 0xb3, 0x63, 0xb0, 0xd1,
-	0x65, 0xdf, 0xf0, 0xd8, 0x80, 0xda, 0x63, 0xea, 0xc1, 0xa7, 0x60, 0x63, 0x40, 0xbd, 0xb7, 0xd6,
-	0x88, 0xbe, 0x32, 0xa6, 0x14, 0x95, 0xf6, 0x4a, 0xfb, 0x95, 0xce, 0xc3, 0x30, 0x50, 0xee, 0xf9,
-	0x71, 0x79, 0x68, 0x1b, 0x53, 0xfa, 0xd8, 0x99, 0x5a, 0x8c, 0x4e, 0x5d, 0x76, 0xa5, 0x67, 0xe5,
-	0xf0, 0x39, 0xa8, 0x26, 0x1f, 0x7b, 0xb6, 0xcf, 0x0c, 0x7b, 0x44, 0xd1, 0x2d, 0x4e, 0xa8, 0x85,
-	0x81, 0xf2, 0x50, 0x10, 0xac, 0x64, 0x2c, 0x43, 0x91, 0x6d, 0x50, 0x05, 0xe5, 0xe7, 0x8e, 0xcf,
-	0xf8, 0x24, 0x96, 0x39, 0xe2, 0x7e, 0x18, 0x28, 0x77, 0x2e, 0x1d, 0x9f, 0xc9, 0x33, 0x98, 0x0b,
-	0xeb, 0x7f, 0x5d, 0x06, 0x9b, 0xd9, 0xef, 0x04, 0x1f, 0x81, 0x0a, 0xff, 0x70, 0xfe, 0xba, 0x77,
-	0x9a, 0xcc, 0xa4, 0x1c, 0x06, 0xca, 0x8a, 0x39, 0xb3, 0xc6, 0x7a, 0x3a, 0x04, 0x0f, 0x01, 0xd0,
-	0xa9, 0x3b, 0x31, 0x46, 0xb4, 0x3d, 0x99, 0xf0, 0xeb, 0x95, 0x3b, 0xd5, 0x30, 0x50, 0x36, 0xbc,
-	0xb8, 0x3a, 0x34, 0x26, 0x13, 0x3d, 0x23, 0x81, 0x8f, 0x01, 0xe8, 0xf9, 0x5d, 0x67, 0xea, 0x4e,
-	0x28, 0xa3, 0x68, 0x85, 0x1b, 0x36, 0xc3, 0x40, 0x29, 0x8f, 0x92, 0x9a, 0x9e, 0x19, 0x87, 0x4f,
-	0xc0, 0xfa, 0x6b, 0xd7, 0xa7, 0x1e, 0xf3, 0xd1, 0xea, 0xde, 0xf2, 0xfe, 0x06, 0xd9, 0x3e, 0xe0,
-	0x3f, 0xcd, 0xc1, 0x85, 0x61, 0xc6, 0x03, 0x9d, 0xea, 0x8f, 0x81, 0xb2, 0x14, 0x06, 0xca, 0xfa,
-	0x2c, 0x16, 0xea, 0xc2, 0x11, 0x99, 0x4f, 0x69, 0x84, 0xf1, 0xd1, 0x9a, 0x6c, 0x8e, 0x07, 0x52,
-	0xf3, 0x38, 0x16, 0xea, 0xc2, 0x11, 0x7d, 0xb1, 0x8b, 0x8b, 0x17, 0x2f, 0x2d, 0x7b, 0x16, 0xf9,
-	0xd7, 0xf7, 0x4a, 0xfb, 0x5b, 0xf1, 0x17, 0x63, 0x6c, 0x32, 0x9c, 0xc6, 0x65, 0x3d, 0x23, 0x81,
-	0x6d, 0xb0, 0x16, 0x27, 0x01, 0x95, 0xf7, 0x4a, 0xfb, 0x1b, 0xe4, 0x41, 0x7a, 0x31, 0x29, 0x2a,
-	0x9d, 0xdb, 0xc9, 0x55, 0xd7, 0x7c, 0xfe, 0x59, 0x4f, 0x8c, 0x7f, 0x5c, 0x29, 0x97, 0xb6, 0x6f,
-	0xd5, 0xff, 0x04, 0xe0, 0xb3, 0x09, 0x7d, 0x37, 0x60, 0x9e, 0x65, 0x9b, 0x5d, 0xcf, 0x62, 0xd4,
-	0xb3, 0x0c, 0x58, 0x07, 0x6b, 0xed, 0x11, 0xb3, 0x1c, 0x3b, 0x49, 0x16, 0x88, 0xfc, 0x06, 0xaf,
-	0xe8, 0xc9, 0x08, 0x54, 0xc0, 0xea, 0x77, 0xc6, 0x64, 0x26, 0xa2, 0x53, 0x09, 0x03, 0x65, 0xf5,
-	0x6d, 0x54, 0xd0, 0xe3, 0x7a, 0xfd, 0x9f, 0x5f, 0x82, 0x8d, 0x0b, 0x23, 0x85, 0x7e, 0x0d, 0x2a,
-	0xa7, 0x96, 0x47, 0xb3, 0xdc, 0xad, 0x30, 0x50, 0x2a, 0x63, 0x51, 0xd4, 0xd3, 0x71, 0x48, 0x00,
-	0xe8, 0x3b, 0x1e, 0xd3, 0x0d, 0xdb, 0xa4, 0x3e, 0xba, 0xb5, 0xb7, 0xbc, 0x5f, 0xe9, 0xc0, 0x30,
-	0x50, 0x6e, 0xbb, 0x8e, 0xc7, 0x32, 0xa9, 0xca, 0xa8, 0xa0, 0x06, 0x2a, 0xfd, 0xe8, 0xa1, 0x19,
-	0x39, 0x13, 0x1f, 0x2d, 0xef, 0x2d, 0xef, 0x6f, 0x75, 0xee, 0x85, 0x81, 0x02, 0xdd, 0xa4, 0x98,
-	0xb1, 0xa5, 0x42, 0x78, 0x08, 0x2a, 0xed, 0xc1, 0xab, 0xe4, 0x42, 0x2b, 0xfc, 0x42, 0x3b, 0x61,
-	0xa0, 0x6c, 0x19, 0xbe, 0x9d, 0x35, 0xcc, 0x35, 0x50, 0x05, 0xe0, 0xbb, 0x17, 0x86, 0x9d, 0x38,
-	0x56, 0xb9, 0xe3, 0x4e, 0x18, 0x28, 0xd5, 0xb7, 0x13, 0xc3, 0xf6, 0xb3, 0x73, 0x4b, 0x65, 0xf0,
-	0x1c, 0x54, 0x5f, 0x18, 0x3e, 0x7b, 0xee, 0xb8, 0xed, 0xc1, 0xab, 0xe8, 0x29, 0x88, 0x63, 0x52,
-	0xe9, 0x7c, 0x11, 0x06, 0xca, 0x83, 0x89, 0xe1, 0xb3, 0x4b, 0xc7, 0x1d, 0x1a, 0xbe, 0xfc, 0xd4,
-	0xc8, 0x2e, 0x78, 0x06, 0xb6, 0x5f, 0xd1, 0x77, 0x49, 0x29, 0x99, 0xc3, 0x3a, 0x27, 0x3d, 0x08,
-	0x03, 0xe5, 0xae, 0x4d, 0xdf, 0x25, 0xa4, 0xec, 0xec, 0x73, 0x96, 0x68, 0x3e, 0x69, 0x2d, 0x9e,
-	0x4f, 0x39, 0x9d, 0x4f, 0x4a, 0xc9, 0xcd, 0x47, 0x72, 0xc1, 0x63, 0x00, 0x3a, 0xe7, 0xfd, 0xf6,
-	0xa0, 0x6f, 0xb0, 0x4b, 0x1f, 0x55, 0x38, 0x03, 0x85, 0x81, 0xb2, 0xfb, 0xc6, 0x8c, 0xfc, 0xae,
-	0xc1, 0x2e, 0xb3, 0xb7, 0x24, 0xd5, 0xc2, 0x2e, 0xb8, 0xdd, 0x39, 0xef, 0x77, 0x9d, 0xe9, 0x74,
-	0x66, 0x5b, 0xcc, 0xa2, 0x3e, 0x02, 0xdc, 0xfd, 0x59, 0x18, 0x28, 0xf7, 0x23, 0xf7, 0x28, 0x19,
-	0xba, 0xca, 0x00, 0x24, 0x4b, 0xb4, 0x00, 0x5d, 0x74, 0xfb, 0xcf, 0x26, 0x86, 0xe9, 0xa3, 0x0d,
-	0xfe, 0xdc, 0xf0, 0x05, 0x88, 0x8d, 0xdc, 0xe1, 0xf7, 0x51, 0x31, 0xbb, 0x00, 0x09, 0x21, 0xd4,
-	0xc0, 0x46, 0xaf, 0xdf, 0x1e, 0x8f, 0x3d, 0xea, 0xfb, 0xd4, 0x47, 0x9b, 0x69, 0xba, 0x8c, 0xf1,
-	0xd8, 0xcb, 0xae, 0x9a, 0x19, 0x19, 0x6c, 0x82, 0xcd, 0x97, 0xed, 0x6e, 0x6a, 0xdb, 0x4a, 0xb3,
-	0x32, 0x35, 0x46, 0x19, 0xd7, 0x82, 0x0c, 0x9e, 0x80, 0xcd, 0xae, 0x33, 0xb3, 0x99, 0x77, 0xd5,
-	0x75, 0xc6, 0xd4, 0x47, 0xb7, 0xb9, 0xed, 0x6e, 0x18, 0x28, 0x3b, 0xa3, 0xb8, 0x9e, 0xb5, 0x66,
-	0xa5, 0xf0, 0x29, 0xa8, 0x0e, 0x2c, 0xc6, 0xd7, 0x6c, 0x9d, 0x9a, 0xf4, 0x1d, 0xf5, 0x51, 0x35,
-	0x9d, 0xab, 0x6f, 0xb1, 0xc5, 0xb5, 0x79, 0x51, 0x0a, 0xcf, 0xc1, 0xce, 0x29, 0x8d, 0x56, 0xeb,
-	0x8b, 0x2b, 0x77, 0xee, 0xdf, 0x4e, 0xa3, 0x32, 0xe6, 0x83, 0x43, 0x76, 0xe5, 0x66, 0x31, 0x79,
-	0x0f, 0xec, 0x83, 0xdd, 0x9e, 0xcd, 0xa8, 0xf7, 0xbd, 0x31, 0x5a, 0x98, 0xcb, 0x0e, 0x67, 0x7d,
-	0x1e, 0x06, 0x0a, 0xb2, 0xc4, 0xb8, 0x9c, 0x97, 0x1b, 0x9d, 0xe9, 0xd4, 0xb2, 0x38, 0x98, 0x9b,
-	0x9a, 0xc4, 0xca, 0x7b, 0xe0, 0x19, 0x80, 0x49, 0x20, 0xb3, 0x3f, 0xe8, 0x9d, 0xf4, 0x16, 0x27,
-	0x49, 0xce, 0x50, 0x6e, 0x30, 0xc0, 0xe7, 0x60, 0x75, 0xc0, 0xbc, 0x46, 0x03, 0xed, 0xf2, 0xa5,
-	0x5b, 0xac, 0xa6, 0xf9, 0x95, 0xb1, 0x73, 0x3f, 0x59, 0x4d, 0xab, 0x7e, 0xa4, 0xcf, 0x60, 0x63,
-	0x80, 0x20, 0x61, 0x74, 0xb7, 0x10, 0x09, 0xcb, 0x24, 0x2c, 0x48, 0x04, 0xdd, 0x2b, 0x44, 0x22,
-	0x32, 0x89, 0x08, 0x92, 0x8a, 0xee, 0x17, 0x22, 0xa9, 0x32, 0x49, 0x15, 0x24, 0x0d, 0xa1, 0x42,
-	0x24, 0x4d, 0x26, 0x69, 0x82, 0xd4, 0x44, 0x0f, 0x0a, 0x91, 0x9a, 0x32, 0xa9, 0x29, 0x48, 0x2d,
-	0xf4, 0xb0, 0x10, 0xa9, 0x25, 0x93, 0x5a, 0x82, 0x74, 0x84, 0x3e, 0x2b, 0x44, 0x3a, 0x92, 0x49,
-	0x47, 0x82, 0x74, 0x8c, 0x3e, 0x2f, 0x44, 0x3a, 0x96, 0x49, 0xc7, 0x82, 0x74, 0x82, 0xbe, 0x28,
-	0x44, 0x3a, 0x91, 0x49, 0x27, 0x09, 0x09, 0x37, 0x50, 0xad, 0x08, 0x09, 0xcb, 0x19, 0xc7, 0x22,
-	0xe3, 0x18, 0x23, 0xa5, 0x10, 0x49, 0xce, 0x38, 0x16, 0x19, 0xc7, 0x04, 0xed, 0x15, 0x22, 0xc9,
-	0x19, 0xc7, 0x22, 0xe3, 0x58, 0x45, 0xbf, 0x28, 0x44, 0x92, 0x33, 0x8e, 0x45, 0xc6, 0xb1, 0x86,
-	0xea, 0x85, 0x48, 0x72, 0xc6, 0xb1, 0xc8, 0x38, 0x6e, 0xa2, 0x2f, 0x0b, 0x91, 0xe4, 0x8c, 0x63,
-	0x91, 0x71, 0xdc, 0x42, 0xbf, 0x2c, 0x44, 0x92, 0x33, 0x8e, 0x5b, 0xb0, 0x01, 0xd6, 0x7b, 0x36,
-	0x6b, 0x69, 0x8d, 0x06, 0xfa, 0x15, 0x5f, 0x25, 0xf9, 0x0e, 0xc9, 0x8a, 0x4a, 0xc3, 0x85, 0xf5,
-	0x4c, 0xc8, 0x52, 0x07, 0x46, 0x8f, 0x72, 0x0e, 0x9c, 0x77, 0xe0, 0xd4, 0x41, 0xd0, 0xaf, 0x73,
-	0x0e, 0x92, 0x77, 0x90, 0xd4, 0xa1, 0xa2, 0xfd, 0x9c, 0x43, 0xcd, 0x3b, 0xd4, 0xd4, 0xa1, 0xa1,
-	0xaf, 0x72, 0x0e, 0x2d, 0xef, 0xd0, 0xa2, 0x57, 0xa6, 0xb6, 0xeb, 0x8a, 0x7d, 0x1f, 0xfa, 0x0d,
-	0x77, 0xf1, 0x57, 0x26, 0xc3, 0x75, 0x87, 0x37, 0xec, 0x11, 0xb3, 0x72, 0xf8, 0x15, 0x58, 0xed,
-	0xd9, 0xac, 0xd1, 0x40, 0x5f, 0xa7, 0xfb, 0x3d, 0x2b, 0x2a, 0x64, 0x6f, 0x31, 0x57, 0x08, 0x29,
-	0x46, 0x8f, 0x17, 0xa5, 0x58, 0x96, 0x62, 0x21, 0x25, 0xe8, 0x9b, 0x45, 0x29, 0x91, 0xa5, 0x44,
-	0x48, 0x55, 0x74, 0xb0, 0x28, 0x55, 0x65, 0xa9, 0x2a, 0xa4, 0x1a, 0x3a, 0x5c, 0x94, 0x6a, 0xb2,
-	0x54, 0x13, 0xd2, 0x26, 0x6a, 0x2c, 0x4a, 0x9b, 0xb2, 0xb4, 0x09, 0xbf, 0x01, 0x6b, 0x3d, 0x9b,
-	0x46, 0xb7, 0x00, 0xa7, 0xed, 0xd5, 0xb2, 0x29, 0x5b, 0xcc, 0x4d, 0x22, 0x9a, 0xcb, 0x31, 0x22,
-	0xb2, 0x1c, 0xe7, 0xe4, 0x78, 0x2e, 0x27, 0x48, 0x95, 0xe5, 0x24, 0x27, 0x27, 0x73, 0xb9, 0x8a,
-	0x34, 0x59, 0xae, 0xe6, 0xe4, 0xea, 0x5c, 0xae, 0xa1, 0xa6, 0x2c, 0xd7, 0x72, 0xf2, 0xf9, 0x83,
-	0x7b, 0x84, 0x5a, 0x85, 0x1e, 0x37, 0xb9, 0x11, 0x60, 0xd1, 0x08, 0xf0, 0x31, 0x3a, 0x2a, 0x44,
-	0x92, 0x1b, 0x01, 0x16, 0x8d, 0x00, 0x9f, 0xa0, 0xe3, 0x42, 0x24, 0xb9, 0x11, 0x60, 0xd1, 0x08,
-	0x48, 0x03, 0x9d, 0x14, 0x21, 0x11, 0xb9, 0x11, 0x10, 0xd1, 0x08, 0x08, 0x46, 0xbf, 0x2d, 0x44,
-	0x92, 0x1b, 0x01, 0x11, 0x8d, 0x80, 0x10, 0xf4, 0xa4, 0x10, 0x49, 0x6e, 0x04, 0x44, 0x34, 0x02,
-	0xa2, 0xa2, 0xa7, 0x85, 0x48, 0x72, 0x23, 0x20, 0xa2, 0x11, 0x10, 0x0d, 0xfd, 0xae, 0x10, 0x49,
-	0x6e, 0x04, 0x44, 0xe4, 0x89, 0x34, 0xd1, 0xef, 0x0b, 0x91, 0xe4, 0x46, 0x40, 0x44, 0x23, 0x20,
-	0x2d, 0xf4, 0x87, 0x42, 0x24, 0xb9, 0x11, 0x10, 0xb1, 0xd9, 0x21, 0x47, 0xe8, 0xdb, 0x42, 0x24,
-	0x39, 0xe3, 0x44, 0x64, 0x9c, 0x1c, 0xa3, 0x76, 0x21, 0x92, 0x9c, 0x71, 0x22, 0x32, 0x4e, 0x4e,
-	0x50, 0xa7, 0x10, 0x49, 0xce, 0x38, 0x11, 0x19, 0x57, 0x1b, 0xa8, 0x5b, 0x84, 0xa4, 0xca, 0x19,
-	0x57, 0x45, 0xc6, 0x55, 0x8c, 0x4e, 0x0b, 0x91, 0xe4, 0x8c, 0xab, 0x22, 0xe3, 0x2a, 0x41, 0x67,
-	0x85, 0x48, 0x72, 0xc6, 0x55, 0x12, 0xbd, 0x90, 0xc5, 0xaf, 0x42, 0x83, 0xd9, 0x1b, 0x96, 0x79,
-	0xb9, 0x7b, 0x96, 0xbe, 0x90, 0x25, 0x6f, 0x50, 0x7e, 0x2c, 0xc8, 0xbe, 0x90, 0xdd, 0xe4, 0xac,
-	0xdb, 0xa0, 0x32, 0x3f, 0xe4, 0x4a, 0x4f, 0x76, 0x4a, 0x37, 0x9f, 0xec, 0xc0, 0x6f, 0x41, 0x59,
-	0xcc, 0x95, 0x1f, 0xcd, 0x6c, 0x10, 0x98, 0x9e, 0x3f, 0xcd, 0xbf, 0xc5, 0x76, 0xf2, 0x2d, 0xca,
-	0xa3, 0xa4, 0xa2, 0xcf, 0x5d, 0xf5, 0xc7, 0xfc, 0x7a, 0xf1, 0xf1, 0xd7, 0xff, 0xbc, 0x5e, 0x9d,
-	0x80, 0xb2, 0x38, 0xb9, 0x80, 0xbb, 0xd1, 0x5d, 0x34, 0x3c, 0xc6, 0xc5, 0x5b, 0x7a, 0xfc, 0x01,
-	0x6e, 0x83, 0xe5, 0x33, 0x7b, 0xcc, 0x8f, 0xa2, 0xb6, 0xf4, 0xe8, 0xdf, 0xba, 0x0a, 0x2a, 0xf3,
-	0xe3, 0x97, 0x22, 0xa6, 0xf9, 0x79, 0xd2, 0xff, 0x6d, 0x3a, 0x01, 0xd5, 0xe8, 0x37, 0x7c, 0x6d,
-	0xd9, 0x4c, 0x25, 0x3f, 0xdb, 0xda, 0xd2, 0x6e, 0xb0, 0xae, 0xdc, 0x60, 0x5d, 0x89, 0xad, 0x7f,
-	0x2f, 0x81, 0x9d, 0x76, 0xbf, 0x37, 0xa0, 0xde, 0x5b, 0xea, 0xe9, 0xd4, 0x77, 0x1d, 0xdb, 0xa7,
-	0xf0, 0x11, 0x58, 0xe1, 0x87, 0xa8, 0xf1, 0x9d, 0xe4, 0xc7, 0x04, 0xe6, 0xcc, 0x1a, 0x67, 0x7e,
-	0xff, 0x95, 0xe4, 0x24, 0x75, 0xfd, 0x25, 0xf5, 0x7d, 0xc3, 0x14, 0xc7, 0x77, 0xbc, 0x23, 0x4e,
-	0xe3, 0x52, 0x76, 0xf7, 0x94, 0xa8, 0xa2, 0x8d, 0xc2, 0x99, 0xe7, 0x39, 0x5e, 0x72, 0xca, 0xcb,
-	0x37, 0x0a, 0x34, 0x2a, 0x64, 0xd3, 0xc9, 0x15, 0x9d, 0xd7, 0xef, 0x3f, 0xd4, 0x96, 0x7e, 0xfa,
-	0x50, 0x5b, 0xfa, 0xf4, 0xa1, 0x56, 0xfa, 0xcb, 0x75, 0xad, 0xf4, 0x8f, 0xeb, 0x5a, 0xe9, 0xc7,
-	0xeb, 0x5a, 0xe9, 0xfd, 0x75, 0xad, 0xf4, 0xef, 0xeb, 0x5a, 0xe9, 0x3f, 0xd7, 0xb5, 0xa5, 0x4f,
-	0xd7, 0xb5, 0xd2, 0xdf, 0x3e, 0xd6, 0x96, 0xde, 0x7f, 0xac, 0x2d, 0xfd, 0xf4, 0xb1, 0xb6, 0xf4,
-	0x67, 0xc5, 0xb4, 0xd8, 0xe5, 0xec, 0xcd, 0xc1, 0xc8, 0x99, 0x1e, 0xfe, 0x40, 0x6d, 0x66, 0xfd,
-	0x70, 0x68, 0x3a, 0x3c, 0x54, 0x4f, 0xf8, 0xdf, 0x37, 0x6b, 0x7c, 0x8b, 0xa6, 0xfe, 0x37, 0x00,
-	0x00, 0xff, 0xff, 0x12, 0x76, 0x84, 0xad, 0x3e, 0x17, 0x00, 0x00,
+	// 1905 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x98, 0x4d, 0x73, 0xdb, 0xb8,
+	0xfd, 0xc7, 0xad, 0xc8, 0x0f, 0x12, 0xe4, 0x47, 0xc4, 0x49, 0x90, 0xec, 0xae, 0xe8, 0xbf, 0xf6,
+	0xdf, 0xd4, 0xdb, 0xf5, 0xda, 0x02, 0x28, 0xf9, 0xa1, 0x49, 0xdb, 0x95, 0x6c, 0xc7, 0x51, 0x27,
+	0xc9, 0xa8, 0x94, 0x93, 0x99, 0xf6, 0xa2, 0x61, 0x24, 0xac, 0xcc, 0x59, 0x89, 0xe4, 0x90, 0x50,
+	0x26, 0xbe, 0xf5, 0xda, 0x5b, 0x5f, 0x46, 0x3b, 0x7d, 0x23, 0x7b, 0x4c, 0x6f, 0x7b, 0xe2, 0x34,
+	0xce, 0xa5, 0xc3, 0xd3, 0xbe, 0x84, 0x0e, 0x41, 0x42, 0x84, 0x40, 0xcf, 0x6c, 0xd9, 0x8b, 0xc7,
+	0x02, 0xbe, 0xdf, 0x0f, 0x41, 0xf2, 0x4b, 0xfc, 0xc8, 0x1f, 0x58, 0x63, 0xe6, 0x68, 0x64, 0xd9,
+	0xa3, 0x7d, 0xd7, 0x73, 0x98, 0x03, 0x97, 0xae, 0x2c, 0xd7, 0x75, 0x1e, 0x6d, 0x8f, 0x9c, 0x91,
+	0xc3, 0x47, 0x0e, 0xa2, 0xff, 0xe2, 0xc9, 0xda, 0x3f, 0x0b, 0x00, 0x5e, 0x9a, 0xa3, 0xb6, 0xc9,
+	0x06, 0x57, 0x5d, 0xd3, 0x63, 0x3d, 0x6a, 0x0f, 0xa9, 0x07, 0x9f, 0x82, 0x4a, 0x8f, 0x7a, 0xef,
+	0xac, 0x01, 0x7d, 0x65, 0x4e, 0x28, 0x2a, 0xec, 0x14, 0x76, 0xcb, 0xed, 0x47, 0x61, 0xa0, 0xdd,
+	0xf7, 0xe3, 0xe1, 0xbe, 0x6d, 0x4e, 0xe8, 0x9e, 0x33, 0xb1, 0x18, 0x9d, 0xb8, 0xec, 0xda, 0x90,
+	0xe5, 0xf0, 0x39, 0xd8, 0x48, 0x7e, 0x76, 0x6c, 0x9f, 0x99, 0xf6, 0x80, 0xa2, 0x3b, 0x9c, 0x50,
+	0x0d, 0x03, 0xed, 0x91, 0x20, 0x58, 0xc9, 0x9c, 0x44, 0x51, 0x6d, 0x50, 0x07, 0xa5, 0xe7, 0x8e,
+	0xcf, 0xf8, 0x22, 0x8a, 0x1c, 0xf1, 0x20, 0x0c, 0xb4, 0xbb, 0x57, 0x8e, 0xcf, 0xd4, 0x15, 0xcc,
+	0x84, 0xb5, 0xbf, 0x14, 0xc1, 0xaa, 0x7c, 0x4e, 0xf0, 0x31, 0x28, 0xf3, 0x1f, 0x17, 0xaf, 0x3b,
+	0x67, 0xc9, 0x4a, 0x4a, 0x61, 0xa0, 0x2d, 0x8e, 0xa6, 0xd6, 0xd0, 0x48, 0xa7, 0xe0, 0x01, 0x00,
+	0x06, 0x75, 0xc7, 0xe6, 0x80, 0xb6, 0xc6, 0x63, 0x7e, 0xbc, 0x52, 0x7b, 0x23, 0x0c, 0xb4, 0x8a,
+	0x17, 0x8f, 0xf6, 0xcd, 0xf1, 0xd8, 0x90, 0x24, 0x70, 0x0f, 0x80, 0x8e, 0x7f, 0xea, 0x4c, 0xdc,
+	0x31, 0x65, 0x14, 0x2d, 0x72, 0xc3, 0x6a, 0x18, 0x68, 0xa5, 0x41, 0x32, 0x66, 0x48, 0xf3, 0xf0,
+	0x09, 0x58, 0x79, 0xed, 0xfa, 0xd4, 0x63, 0x3e, 0x5a, 0xda, 0x29, 0xee, 0x56, 0xc8, 0xe6, 0x3e,
+	0xbf, 0x35, 0xfb, 0x97, 0xe6, 0x28, 0x9e, 0x68, 0x6f, 0xfc, 0x10, 0x68, 0x0b, 0x61, 0xa0, 0xad,
+	0x4c, 0x63, 0xa1, 0x21, 0x1c, 0x91, 0xf9, 0x8c, 0x46, 0x18, 0x1f, 0x2d, 0xab, 0xe6, 0x78, 0x22,
+	0x35, 0x0f, 0x63, 0xa1, 0x21, 0x1c, 0xd1, 0x89, 0x5d, 0x5e, 0xbe, 0x78, 0x69, 0xd9, 0xd3, 0xc8,
+	0xbf, 0xb2, 0x53, 0xd8, 0x5d, 0x8b, 0x4f, 0x8c, 0xb1, 0x71, 0x7f, 0x12, 0x0f, 0x1b, 0x92, 0x04,
+	0xb6, 0xc0, 0x72, 0x9c, 0x04, 0x54, 0xda, 0x29, 0xec, 0x56, 0xc8, 0xc3, 0xf4, 0x60, 0x4a, 0x54,
+	0xda, 0xeb, 0xc9, 0x51, 0x97, 0x7d, 0xfe, 0xdb, 0x48, 0x8c, 0xbf, 0x5f, 0x2c, 0x15, 0x36, 0xef,
+	0xd4, 0xfe, 0x08, 0xe0, 0xb3, 0x31, 0x7d, 0xdf, 0x63, 0x9e, 0x65, 0x8f, 0x4e, 0x3d, 0x8b, 0x51,
+	0xcf, 0x32, 0x61, 0x0d, 0x2c, 0xb7, 0x06, 0xcc, 0x72, 0xec, 0x24, 0x59, 0x20, 0xf2, 0x9b, 0x7c,
+	0xc4, 0x48, 0x66, 0xa0, 0x06, 0x96, 0xde, 0x98, 0xe3, 0xa9, 0x88, 0x4e, 0x39, 0x0c, 0xb4, 0xa5,
+	0x77, 0xd1, 0x80, 0x11, 0x8f, 0xd7, 0xfe, 0xfe, 0x25, 0xa8, 0x5c, 0x9a, 0x29, 0xf4, 0x6b, 0x50,
+	0x3e, 0xb3, 0x3c, 0x2a, 0x73, 0xd7, 0xc2, 0x40, 0x2b, 0x0f, 0xc5, 0xa0, 0x91, 0xce, 0x43, 0x02,
+	0x40, 0xd7, 0xf1, 0x98, 0x61, 0xda, 0x23, 0xea, 0xa3, 0x3b, 0x3b, 0xc5, 0xdd, 0x72, 0x1b, 0x86,
+	0x81, 0xb6, 0xee, 0x3a, 0x1e, 0x93, 0x52, 0x25, 0xa9, 0x60, 0x03, 0x94, 0xbb, 0xd1, 0x43, 0x33,
+	0x70, 0xc6, 0x3e, 0x2a, 0xee, 0x14, 0x77, 0xd7, 0xda, 0xf7, 0xc3, 0x40, 0x83, 0x6e, 0x32, 0x28,
+	0xd9, 0x52, 0x21, 0x3c, 0x00, 0xe5, 0x56, 0xef, 0x55, 0x72, 0xa0, 0x45, 0x7e, 0xa0, 0xad, 0x30,
+	0xd0, 0xd6, 0x4c, 0xdf, 0x96, 0x0d, 0x33, 0x0d, 0xd4, 0x01, 0x78, 0xf3, 0xc2, 0xb4, 0x13, 0xc7,
+	0x12, 0x77, 0xdc, 0x0d, 0x03, 0x6d, 0xe3, 0xdd, 0xd8, 0xb4, 0x7d, 0x79, 0x6d, 0xa9, 0x0c, 0x5e,
+	0x80, 0x8d, 0x17, 0xa6, 0xcf, 0x9e, 0x3b, 0x6e, 0xab, 0xf7, 0x2a, 0x7a, 0x0a, 0xe2, 0x98, 0x94,
+	0xdb, 0x5f, 0x84, 0x81, 0xf6, 0x70, 0x6c, 0xfa, 0xec, 0xca, 0x71, 0xfb, 0xa6, 0xaf, 0x3e, 0x35,
+	0xaa, 0x0b, 0x9e, 0x83, 0xcd, 0x57, 0xf4, 0x7d, 0x32, 0x94, 0xac, 0x61, 0x85, 0x93, 0x1e, 0x86,
+	0x81, 0x76, 0xcf, 0xa6, 0xef, 0x13, 0x92, 0xbc, 0xfa, 0x8c, 0x25, 0x5a, 0x4f, 0x3a, 0x16, 0xaf,
+	0xa7, 0x94, 0xae, 0x27, 0xa5, 0x64, 0xd6, 0xa3, 0xb8, 0xe0, 0x31, 0x00, 0xed, 0x8b, 0x6e, 0xab,
+	0xd7, 0x35, 0xd9, 0x95, 0x8f, 0xca, 0x9c, 0x81, 0xc2, 0x40, 0xdb, 0x7e, 0x3b, 0x8a, 0xfc, 0xae,
+	0xc9, 0xae, 0xe4, 0x4b, 0x92, 0x6a, 0xe1, 0x29, 0x58, 0x6f, 0x5f, 0x74, 0x4f, 0x9d, 0xc9, 0x64,
+	0x6a, 0x5b, 0xcc, 0xa2, 0x3e, 0x02, 0xdc, 0xfd, 0x59, 0x18, 0x68, 0x0f, 0x22, 0xf7, 0x20, 0x99,
+	0xba, 0x96, 0x00, 0x8a, 0x25, 0xda, 0x80, 0x2e, 0x4f, 0xbb, 0xcf, 0xc6, 0xe6, 0xc8, 0x47, 0x15,
+	0xfe, 0xdc, 0xf0, 0x0d, 0x88, 0x0d, 0xdc, 0xfe, 0x77, 0xd1, 0xa0, 0xbc, 0x01, 0x09, 0x21, 0x6c,
+	0x80, 0x4a, 0xa7, 0xdb, 0x1a, 0x0e, 0x3d, 0xea, 0xfb, 0xd4, 0x47, 0xab, 0x69, 0xba, 0xcc, 0xe1,
+	0xd0, 0x93, 0x77, 0x4d, 0x49, 0x06, 0x9b, 0x60, 0xf5, 0x65, 0xeb, 0x34, 0xb5, 0xad, 0xa5, 0x59,
+	0x99, 0x98, 0x03, 0xc9, 0x35, 0x27, 0x83, 0x27, 0x60, 0xf5, 0xd4, 0x99, 0xda, 0xcc, 0xbb, 0x3e,
+	0x75, 0x86, 0xd4, 0x47, 0xeb, 0xdc, 0x76, 0x2f, 0x0c, 0xb4, 0xad, 0x41, 0x3c, 0x2e, 0x5b, 0x65,
+	0x29, 0x7c, 0x0a, 0x36, 0x7a, 0x16, 0xe3, 0x7b, 0xb6, 0x41, 0x47, 0xf4, 0x3d, 0xf5, 0xd1, 0x46,
+	0xba, 0x56, 0xdf, 0x62, 0xf3, 0x7b, 0xf3, 0xbc, 0x14, 0x5e, 0x80, 0xad, 0x33, 0x1a, 0xed, 0xd6,
+	0x97, 0xd7, 0xee, 0xcc, 0xbf, 0x99, 0x46, 0x65, 0xc8, 0x27, 0xfb, 0xec, 0xda, 0x95, 0x31, 0x59,
+	0x0f, 0xec, 0x82, 0xed, 0x8e, 0xcd, 0xa8, 0xf7, 0x9d, 0x39, 0x98, 0x5b, 0xcb, 0x16, 0x67, 0x7d,
+	0x1e, 0x06, 0x1a, 0xb2, 0xc4, 0xbc, 0x9a, 0x97, 0x5b, 0x9d, 0xe9, 0xd2, 0x64, 0x1c, 0xcc, 0x2c,
+	0x4d, 0x61, 0x65, 0x3d, 0xf0, 0x1c, 0xc0, 0x24, 0x90, 0xf2, 0x0d, 0xbd, 0x9b, 0x5e, 0xe2, 0x24,
+	0xc9, 0x12, 0xe5, 0x16, 0x03, 0x7c, 0x0e, 0x96, 0x7a, 0xcc, 0xab, 0xd7, 0xd1, 0xf6, 0x4e, 0x51,
+	0xda, 0x4d, 0xb3, 0x3b, 0x63, 0xfb, 0x41, 0xb2, 0x9b, 0x6e, 0xf8, 0x91, 0x5e, 0xc2, 0xc6, 0x00,
+	0x41, 0xc2, 0xe8, 0x5e, 0x2e, 0x12, 0x56, 0x49, 0x58, 0x90, 0x08, 0xba, 0x9f, 0x8b, 0x44, 0x54,
+	0x12, 0x11, 0x24, 0x1d, 0x3d, 0xc8, 0x45, 0xd2, 0x55, 0x92, 0x2e, 0x48, 0x0d, 0x84, 0x72, 0x91,
+	0x1a, 0x2a, 0xa9, 0x21, 0x48, 0x4d, 0xf4, 0x30, 0x17, 0xa9, 0xa9, 0x92, 0x9a, 0x82, 0x74, 0x88,
+	0x1e, 0xe5, 0x22, 0x1d, 0xaa, 0xa4, 0x43, 0x41, 0x3a, 0x42, 0x9f, 0xe5, 0x22, 0x1d, 0xa9, 0xa4,
+	0x23, 0x41, 0x3a, 0x46, 0x9f, 0xe7, 0x22, 0x1d, 0xab, 0xa4, 0x63, 0x41, 0x3a, 0x41, 0x5f, 0xe4,
+	0x22, 0x9d, 0xa8, 0xa4, 0x93, 0x84, 0x84, 0xeb, 0xa8, 0x9a, 0x87, 0x84, 0xd5, 0x8c, 0x63, 0x91,
+	0x71, 0x8c, 0x91, 0x96, 0x8b, 0xa4, 0x66, 0x1c, 0x8b, 0x8c, 0x63, 0x82, 0x76, 0x72, 0x91, 0xd4,
+	0x8c, 0x63, 0x91, 0x71, 0xac, 0xa3, 0xff, 0xcb, 0x45, 0x52, 0x33, 0x8e, 0x45, 0xc6, 0x71, 0x03,
+	0xd5, 0x72, 0x91, 0xd4, 0x8c, 0x63, 0x91, 0x71, 0xdc, 0x44, 0x5f, 0xe6, 0x22, 0xa9, 0x19, 0xc7,
+	0x22, 0xe3, 0xf8, 0x10, 0xfd, 0x7f, 0x2e, 0x92, 0x9a, 0x71, 0x7c, 0x08, 0xeb, 0x60, 0xa5, 0x63,
+	0xb3, 0xc3, 0x46, 0xbd, 0x8e, 0x7e, 0xc1, 0x77, 0x49, 0xfe, 0x86, 0x64, 0x45, 0x43, 0xfd, 0xb9,
+	0xfd, 0x4c, 0xc8, 0x52, 0x07, 0x46, 0x8f, 0x33, 0x0e, 0x9c, 0x75, 0xe0, 0xd4, 0x41, 0xd0, 0x2f,
+	0x33, 0x0e, 0x92, 0x75, 0x90, 0xd4, 0xa1, 0xa3, 0xdd, 0x8c, 0x43, 0xcf, 0x3a, 0xf4, 0xd4, 0xd1,
+	0x40, 0x5f, 0x65, 0x1c, 0x8d, 0xac, 0xa3, 0x11, 0x7d, 0x32, 0xb5, 0x5c, 0x57, 0xbc, 0xf7, 0xa1,
+	0x5f, 0xed, 0x14, 0xc5, 0x27, 0x93, 0xe9, 0xba, 0xfd, 0x5b, 0xde, 0x11, 0x65, 0x39, 0xfc, 0x0a,
+	0x2c, 0x75, 0x6c, 0x56, 0xaf, 0xa3, 0xaf, 0xd3, 0xf7, 0x3d, 0x2b, 0x1a, 0x90, 0x2f, 0x31, 0x57,
+	0x08, 0x29, 0x46, 0x7b, 0xf3, 0x52, 0xac, 0x4a, 0xb1, 0x90, 0x12, 0xf4, 0xcd, 0xbc, 0x94, 0xa8,
+	0x52, 0x22, 0xa4, 0x3a, 0xda, 0x9f, 0x97, 0xea, 0xaa, 0x54, 0x17, 0xd2, 0x06, 0x3a, 0x98, 0x97,
+	0x36, 0x54, 0x69, 0x43, 0x48, 0x9b, 0xa8, 0x3e, 0x2f, 0x6d, 0xaa, 0xd2, 0x26, 0xfc, 0x06, 0x2c,
+	0x77, 0x6c, 0x1a, 0x5d, 0x02, 0x9c, 0x96, 0x57, 0xcb, 0xa6, 0x6c, 0x3e, 0x37, 0x89, 0x68, 0x26,
+	0xc7, 0x88, 0xa8, 0x72, 0x9c, 0x91, 0xe3, 0x99, 0x9c, 0x20, 0x5d, 0x95, 0x93, 0x8c, 0x9c, 0xcc,
+	0xe4, 0x3a, 0x6a, 0xa8, 0x72, 0x3d, 0x23, 0xd7, 0x67, 0xf2, 0x06, 0x6a, 0xaa, 0xf2, 0x46, 0x46,
+	0x3e, 0x7b, 0x70, 0x8f, 0xd0, 0x61, 0xae, 0xc7, 0x4d, 0x2d, 0x04, 0x58, 0x14, 0x02, 0x7c, 0x8c,
+	0x8e, 0x72, 0x91, 0xd4, 0x42, 0x80, 0x45, 0x21, 0xc0, 0x27, 0xe8, 0x38, 0x17, 0x49, 0x2d, 0x04,
+	0x58, 0x14, 0x02, 0x52, 0x47, 0x27, 0x79, 0x48, 0x44, 0x2d, 0x04, 0x44, 0x14, 0x02, 0x82, 0xd1,
+	0xaf, 0x73, 0x91, 0xd4, 0x42, 0x40, 0x44, 0x21, 0x20, 0x04, 0x3d, 0xc9, 0x45, 0x52, 0x0b, 0x01,
+	0x11, 0x85, 0x80, 0xe8, 0xe8, 0x69, 0x2e, 0x92, 0x5a, 0x08, 0x88, 0x28, 0x04, 0xa4, 0x81, 0x7e,
+	0x93, 0x8b, 0xa4, 0x16, 0x02, 0x22, 0xf2, 0x44, 0x9a, 0xe8, 0xb7, 0xb9, 0x48, 0x6a, 0x21, 0x20,
+	0xa2, 0x10, 0x90, 0x43, 0xf4, 0xbb, 0x5c, 0x24, 0xb5, 0x10, 0x10, 0xf1, 0xb2, 0x43, 0x8e, 0xd0,
+	0xb7, 0xb9, 0x48, 0x6a, 0xc6, 0x89, 0xc8, 0x38, 0x39, 0x46, 0xad, 0x5c, 0x24, 0x35, 0xe3, 0x44,
+	0x64, 0x9c, 0x9c, 0xa0, 0x76, 0x2e, 0x92, 0x9a, 0x71, 0x22, 0x32, 0xae, 0xd7, 0xd1, 0x69, 0x1e,
+	0x92, 0xae, 0x66, 0x5c, 0x17, 0x19, 0xd7, 0x31, 0x3a, 0xcb, 0x45, 0x52, 0x33, 0xae, 0x8b, 0x8c,
+	0xeb, 0x04, 0x9d, 0xe7, 0x22, 0xa9, 0x19, 0xd7, 0x49, 0xf4, 0x41, 0x16, 0x7f, 0x0a, 0xf5, 0xa6,
+	0x6f, 0x99, 0xf4, 0x71, 0xf7, 0x2c, 0xfd, 0x20, 0x4b, 0xbe, 0xa0, 0xfc, 0x58, 0x20, 0x7f, 0x90,
+	0xdd, 0xe6, 0xac, 0xd9, 0xa0, 0x3c, 0x6b, 0x72, 0xa5, 0x9d, 0x9d, 0xc2, 0xed, 0x9d, 0x1d, 0xf8,
+	0x2d, 0x28, 0x89, 0xb5, 0xf2, 0xd6, 0x4c, 0x85, 0xc0, 0xb4, 0xff, 0x34, 0x3b, 0x8b, 0xcd, 0xe4,
+	0x2c, 0x4a, 0x83, 0x64, 0xc4, 0x98, 0xb9, 0x6a, 0x7b, 0xfc, 0x78, 0x71, 0xfb, 0xeb, 0x67, 0x8f,
+	0x57, 0x23, 0xa0, 0x24, 0x3a, 0x17, 0x70, 0x3b, 0xba, 0x8a, 0xa6, 0xc7, 0xb8, 0x78, 0xcd, 0x88,
+	0x7f, 0xc0, 0x4d, 0x50, 0x3c, 0xb7, 0x87, 0xbc, 0x15, 0xb5, 0x66, 0x44, 0xff, 0xd6, 0x74, 0x50,
+	0x9e, 0xb5, 0x5f, 0xf2, 0x98, 0x66, 0xfd, 0xa4, 0xff, 0xda, 0x74, 0x02, 0x36, 0xa2, 0x7b, 0xf8,
+	0xda, 0xb2, 0x99, 0x4e, 0xfe, 0x67, 0xeb, 0x61, 0xe3, 0x16, 0xeb, 0xe2, 0x2d, 0xd6, 0xc5, 0xd8,
+	0xfa, 0x07, 0xb0, 0xd6, 0xea, 0x76, 0xf8, 0xf5, 0x39, 0xf7, 0x3c, 0xc7, 0xfb, 0xf9, 0xbb, 0xa6,
+	0x81, 0x25, 0xae, 0x94, 0x1b, 0x76, 0x34, 0x1a, 0x30, 0xe2, 0xf1, 0xda, 0x3f, 0x8a, 0x60, 0xab,
+	0xd5, 0xed, 0x44, 0x3d, 0x5e, 0xea, 0x19, 0xd4, 0x77, 0x1d, 0xdb, 0xa7, 0xf0, 0x31, 0x58, 0xe4,
+	0x7d, 0xd9, 0x18, 0xcb, 0x3b, 0x0f, 0x51, 0x5f, 0x56, 0x8a, 0xd4, 0x62, 0xd2, 0x9c, 0x5d, 0x79,
+	0x49, 0x7d, 0xdf, 0x1c, 0x89, 0x8e, 0x20, 0x2f, 0xb2, 0x93, 0x78, 0x48, 0x7e, 0x21, 0x4b, 0x54,
+	0xd1, 0xbb, 0x47, 0xbc, 0x9e, 0xb8, 0x71, 0xcc, 0xdf, 0x3d, 0xf8, 0x7a, 0xe4, 0xc0, 0xc7, 0xe7,
+	0xb6, 0x07, 0x96, 0x7b, 0xcc, 0x64, 0x53, 0x9f, 0xf7, 0x70, 0xcb, 0xed, 0xed, 0x30, 0xd0, 0x36,
+	0x7d, 0x3e, 0x22, 0x97, 0xef, 0x58, 0x03, 0xcf, 0xc1, 0x7a, 0xd2, 0x95, 0x6d, 0xb9, 0xee, 0xd8,
+	0xa2, 0x43, 0xb4, 0xc4, 0x3b, 0x43, 0xbc, 0xb5, 0x95, 0x34, 0x6e, 0xfb, 0x66, 0x3c, 0x25, 0xb7,
+	0x96, 0xe6, 0x4d, 0x11, 0x26, 0xe9, 0xcf, 0x0a, 0xcc, 0x72, 0x8a, 0x49, 0x5a, 0xb8, 0xb7, 0x61,
+	0xe6, 0x4d, 0xf0, 0x0d, 0xa8, 0xa4, 0x77, 0x29, 0xee, 0xd5, 0x55, 0xc8, 0x76, 0xf2, 0xbc, 0xcc,
+	0xdd, 0xc2, 0x76, 0x35, 0x79, 0x62, 0xee, 0xf3, 0xfb, 0xd6, 0xe7, 0x17, 0x43, 0x3e, 0x41, 0x19,
+	0xd4, 0x7e, 0xfd, 0xe1, 0x63, 0x75, 0xe1, 0xc7, 0x8f, 0xd5, 0x85, 0x9f, 0x3e, 0x56, 0x0b, 0x7f,
+	0xbe, 0xa9, 0x16, 0xfe, 0x76, 0x53, 0x2d, 0xfc, 0x70, 0x53, 0x2d, 0x7c, 0xb8, 0xa9, 0x16, 0xfe,
+	0x75, 0x53, 0x2d, 0xfc, 0xfb, 0xa6, 0xba, 0xf0, 0xd3, 0x4d, 0xb5, 0xf0, 0xd7, 0x4f, 0xd5, 0x85,
+	0x0f, 0x9f, 0xaa, 0x0b, 0x3f, 0x7e, 0xaa, 0x2e, 0xfc, 0x49, 0x1b, 0x59, 0xec, 0x6a, 0xfa, 0x76,
+	0x7f, 0xe0, 0x4c, 0x0e, 0xbe, 0xa7, 0x36, 0xb3, 0xbe, 0x3f, 0x18, 0x39, 0x7c, 0x2d, 0x4f, 0xf8,
+	0xdf, 0xb7, 0xcb, 0xfc, 0x4d, 0x58, 0xff, 0xcf, 0x00, 0xb4, 0x12, 0x0e, 0xe4, 0xa5, 0x18, 0x00,
+	0x00,
 }
 
 func (this *TagBatchPartSender) Equal(that interface{}) bool {
@@ -2251,6 +2345,33 @@ func (this *FlexUint64Range) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *APIValueError) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*APIValueError)
+	if !ok {
+		that2, ok := that.(APIValueError)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Value != that1.Value {
+		return false
+	}
+	if this.Error != that1.Error {
+		return false
+	}
+	return true
+}
 func (this *APIServerResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -2279,6 +2400,23 @@ func (this *APIServerResponse) Equal(that interface{}) bool {
 	if this.Error != that1.Error {
 		return false
 	}
+	if this.Status != that1.Status {
+		return false
+	}
+	if this.UpsertsApplied != that1.UpsertsApplied {
+		return false
+	}
+	if this.DeletesApplied != that1.DeletesApplied {
+		return false
+	}
+	if len(this.ValueErrors) != len(that1.ValueErrors) {
+		return false
+	}
+	for i := range this.ValueErrors {
+		if !this.ValueErrors[i].Equal(&that1.ValueErrors[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *TagBatchPartSender) GoString() string {
@@ -2691,15 +2829,36 @@ func (this *FlexUint64Range) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *APIValueError) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&hippo.APIValueError{")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *APIServerResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 11)
 	s = append(s, "&hippo.APIServerResponse{")
 	s = append(s, "GUID: "+fmt.Sprintf("%#v", this.GUID)+",\n")
 	s = append(s, "Message: "+fmt.Sprintf("%#v", this.Message)+",\n")
 	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
+	s = append(s, "UpsertsApplied: "+fmt.Sprintf("%#v", this.UpsertsApplied)+",\n")
+	s = append(s, "DeletesApplied: "+fmt.Sprintf("%#v", this.DeletesApplied)+",\n")
+	if this.ValueErrors != nil {
+		vs := make([]APIValueError, len(this.ValueErrors))
+		for i := range vs {
+			vs[i] = this.ValueErrors[i]
+		}
+		s = append(s, "ValueErrors: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -4055,6 +4214,43 @@ func (m *FlexUint64Range) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *APIValueError) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *APIValueError) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *APIValueError) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintTagging(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintTagging(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *APIServerResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -4075,6 +4271,37 @@ func (m *APIServerResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.ValueErrors) > 0 {
+		for iNdEx := len(m.ValueErrors) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.ValueErrors[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTagging(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.DeletesApplied != 0 {
+		i = encodeVarintTagging(dAtA, i, uint64(m.DeletesApplied))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.UpsertsApplied != 0 {
+		i = encodeVarintTagging(dAtA, i, uint64(m.UpsertsApplied))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Status) > 0 {
+		i -= len(m.Status)
+		copy(dAtA[i:], m.Status)
+		i = encodeVarintTagging(dAtA, i, uint64(len(m.Status)))
+		i--
+		dAtA[i] = 0x22
+	}
 	if len(m.Error) > 0 {
 		i -= len(m.Error)
 		copy(dAtA[i:], m.Error)
@@ -4716,6 +4943,23 @@ func (m *FlexUint64Range) Size() (n int) {
 	return n
 }
 
+func (m *APIValueError) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovTagging(uint64(l))
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovTagging(uint64(l))
+	}
+	return n
+}
+
 func (m *APIServerResponse) Size() (n int) {
 	if m == nil {
 		return 0
@@ -4734,6 +4978,22 @@ func (m *APIServerResponse) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTagging(uint64(l))
 	}
+	l = len(m.Status)
+	if l > 0 {
+		n += 1 + l + sovTagging(uint64(l))
+	}
+	if m.UpsertsApplied != 0 {
+		n += 1 + sovTagging(uint64(m.UpsertsApplied))
+	}
+	if m.DeletesApplied != 0 {
+		n += 1 + sovTagging(uint64(m.DeletesApplied))
+	}
+	if len(m.ValueErrors) > 0 {
+		for _, e := range m.ValueErrors {
+			l = e.Size()
+			n += 1 + l + sovTagging(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -5117,14 +5377,34 @@ func (this *FlexUint64Range) String() string {
 	}, "")
 	return s
 }
+func (this *APIValueError) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&APIValueError{`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *APIServerResponse) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForValueErrors := "[]APIValueError{"
+	for _, f := range this.ValueErrors {
+		repeatedStringForValueErrors += strings.Replace(strings.Replace(f.String(), "APIValueError", "APIValueError", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForValueErrors += "}"
 	s := strings.Join([]string{`&APIServerResponse{`,
 		`GUID:` + fmt.Sprintf("%v", this.GUID) + `,`,
 		`Message:` + fmt.Sprintf("%v", this.Message) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`Status:` + fmt.Sprintf("%v", this.Status) + `,`,
+		`UpsertsApplied:` + fmt.Sprintf("%v", this.UpsertsApplied) + `,`,
+		`DeletesApplied:` + fmt.Sprintf("%v", this.DeletesApplied) + `,`,
+		`ValueErrors:` + repeatedStringForValueErrors + `,`,
 		`}`,
 	}, "")
 	return s
@@ -8664,6 +8944,120 @@ func (m *FlexUint64Range) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *APIValueError) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTagging
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: APIValueError: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: APIValueError: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTagging
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTagging
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTagging
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTagging
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTagging
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTagging
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTagging(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTagging
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *APIServerResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -8789,6 +9183,110 @@ func (m *APIServerResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Error = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTagging
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTagging
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTagging
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Status = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UpsertsApplied", wireType)
+			}
+			m.UpsertsApplied = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTagging
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UpsertsApplied |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeletesApplied", wireType)
+			}
+			m.DeletesApplied = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTagging
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeletesApplied |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValueErrors", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTagging
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTagging
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTagging
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ValueErrors = append(m.ValueErrors, APIValueError{})
+			if err := m.ValueErrors[len(m.ValueErrors)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTagging(dAtA[iNdEx:])